@@ -0,0 +1,65 @@
+package licenseplease
+
+import (
+	"context"
+	"debug/buildinfo"
+	"fmt"
+	"os"
+)
+
+// BuildInfoResolver implements ModuleResolver using the module versions
+// embedded in a compiled Go binary, so that the report describes exactly
+// what was shipped. Modules are resolved from the module cache, and
+// downloaded into it if necessary.
+type BuildInfoResolver struct {
+	// Binary is the path to the Go executable.
+	Binary string
+}
+
+func (r *BuildInfoResolver) Resolve(ctx context.Context, projectDir string) ([]Module, error) {
+	info, err := buildinfo.ReadFile(r.Binary)
+	if err != nil {
+		return nil, fmt.Errorf("reading build info from %s: %w", r.Binary, err)
+	}
+
+	var modules []Module
+	var queries []string
+	for _, dep := range info.Deps {
+		mod := Module{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != nil {
+			mod.Replace = &Module{Path: dep.Replace.Path, Version: dep.Replace.Version}
+		}
+		// Local filesystem replacements have no version and aren't in the
+		// module cache. Their path is relative to wherever the binary was built.
+		if source := mod.source(); source.Version != "" {
+			queries = append(queries, source.Path+"@"+source.Version)
+		}
+		modules = append(modules, mod)
+	}
+	if len(queries) == 0 {
+		return modules, nil
+	}
+
+	// Download outside of any module or workspace so that the project's own
+	// go.mod can't influence which versions are resolved.
+	downloaded, err := goModDownload(ctx, os.TempDir(), []string{"GOWORK=off", "GOFLAGS=-mod=mod"}, queries...)
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[string]string)
+	for _, m := range downloaded {
+		dirs[m.Path+"@"+m.Version] = m.Dir
+	}
+	for i := range modules {
+		mod := &modules[i]
+		if mod.Local() {
+			continue
+		}
+		source := mod.source()
+		mod.Dir = dirs[source.Path+"@"+source.Version]
+		if mod.Replace != nil {
+			mod.Replace.Dir = mod.Dir
+		}
+	}
+	return modules, nil
+}
@@ -0,0 +1,31 @@
+package licenseplease
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildInfoResolver_NotAGoBinary(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "app")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho hello\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &BuildInfoResolver{Binary: path}
+	if _, err := resolver.Resolve(context.Background(), t.TempDir()); err == nil {
+		t.Error("expected error for a file that isn't a Go binary")
+	}
+}
+
+func TestBuildInfoResolver_MissingBinary(t *testing.T) {
+	t.Parallel()
+
+	resolver := &BuildInfoResolver{Binary: filepath.Join(t.TempDir(), "missing")}
+	if _, err := resolver.Resolve(context.Background(), t.TempDir()); err == nil {
+		t.Error("expected error for a missing binary")
+	}
+}
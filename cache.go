@@ -0,0 +1,197 @@
+package licenseplease
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+)
+
+// cacheVersion is bumped whenever the format or meaning of cache entries
+// changes, so that stale entries are ignored.
+const cacheVersion = 1
+
+// DefaultCacheDir returns the directory license-please caches classification
+// results in, under the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("finding cache directory: %w", err)
+	}
+	return filepath.Join(dir, "license-please"), nil
+}
+
+// CachingClassifier implements LicenseClassifier and HeaderClassifier by
+// caching the results of a GoogleLicenseClassifier on disk. Results are keyed
+// by the SHA-256 of the classified content, along with the classifier's
+// version and settings, so they stay valid across runs and modules. Failing to
+// read or write the cache is not an error: the content is classified as if it
+// weren't cached.
+//
+// Loading the classifier's license database takes most of the time of a
+// fully cached scan, so the GoogleLicenseClassifier is only created on the
+// first cache miss.
+type CachingClassifier struct {
+	// Dir is the directory cached results are stored in.
+	Dir string
+	// CrossCheck is passed on to the GoogleLicenseClassifier.
+	CrossCheck bool
+
+	once       sync.Once
+	classifier *GoogleLicenseClassifier
+	err        error
+}
+
+// google returns the classifier for content that isn't cached, creating it
+// on first use.
+func (c *CachingClassifier) google() (*GoogleLicenseClassifier, error) {
+	c.once.Do(func() {
+		c.classifier, c.err = NewGoogleLicenseClassifier()
+		if c.classifier != nil {
+			c.classifier.CrossCheck = c.CrossCheck
+		}
+	})
+	return c.classifier, c.err
+}
+
+func (c *CachingClassifier) Classify(ctx context.Context, path string) ([]License, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading license file: %w", err)
+	}
+	key := c.key("license", content)
+	if licenses, ok := c.load(key); ok {
+		return licenses, nil
+	}
+	classifier, err := c.google()
+	if err != nil {
+		return nil, err
+	}
+	licenses, err := classifier.Classify(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, licenses)
+	return licenses, nil
+}
+
+func (c *CachingClassifier) ClassifyHeader(ctx context.Context, header []byte) ([]License, error) {
+	key := c.key("header", header)
+	if licenses, ok := c.load(key); ok {
+		return licenses, nil
+	}
+	classifier, err := c.google()
+	if err != nil {
+		return nil, err
+	}
+	licenses, err := classifier.ClassifyHeader(ctx, header)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, licenses)
+	return licenses, nil
+}
+
+// key returns the cache key for classifying content as the given kind of
+// text.
+func (c *CachingClassifier) key(kind string, content []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%t\x00", cacheVersion, classifierVersion(), kind, c.CrossCheck)
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedLicense is the cached form of a License. Its type is derived from its
+// name, and waivers are only ever set by the policy.
+type cachedLicense struct {
+	Name       string  `json:"name"`
+	Confidence float64 `json:"confidence,omitempty"`
+	StartLine  int     `json:"startLine,omitempty"`
+	EndLine    int     `json:"endLine,omitempty"`
+	Declared   string  `json:"declared,omitempty"`
+}
+
+func (c *CachingClassifier) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".json")
+}
+
+func (c *CachingClassifier) load(key string) ([]License, bool) {
+	content, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var cached []cachedLicense
+	if err := json.Unmarshal(content, &cached); err != nil {
+		return nil, false
+	}
+	licenses := make([]License, len(cached))
+	for i, l := range cached {
+		licenses[i] = License{
+			Name:       l.Name,
+			Type:       licenseExpressionOf(l.Name).licenseType(),
+			Confidence: l.Confidence,
+			StartLine:  l.StartLine,
+			EndLine:    l.EndLine,
+			Declared:   l.Declared,
+		}
+	}
+	return licenses, true
+}
+
+// store writes an entry to a temporary file before moving it into place, so
+// that concurrent scans never read a partial entry.
+func (c *CachingClassifier) store(key string, licenses []License) {
+	cached := make([]cachedLicense, len(licenses))
+	for i, l := range licenses {
+		cached[i] = cachedLicense{
+			Name:       l.Name,
+			Confidence: l.Confidence,
+			StartLine:  l.StartLine,
+			EndLine:    l.EndLine,
+			Declared:   l.Declared,
+		}
+	}
+	content, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "entry-*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// classifierVersion returns the version of licenseclassifier built into the
+// binary, since upgrading it can change classification results.
+func classifierVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/google/licenseclassifier/v2" {
+			return dep.Version
+		}
+	}
+	return ""
+}
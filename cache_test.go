@@ -0,0 +1,88 @@
+package licenseplease
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCachingClassifier(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	licensePath := filepath.Join("testdata", "replace", "fork", "LICENSE")
+	header := []byte("Copyright 2024 The Authors\n\nSPDX-License-Identifier: MIT OR Apache-2.0")
+
+	first := &CachingClassifier{Dir: dir}
+	want, err := first.Classify(context.Background(), licensePath)
+	if err != nil {
+		t.Fatalf("Classify() error = %v", err)
+	}
+	if len(want) != 1 || want[0].Name != "MIT" {
+		t.Fatalf("Classify() = %+v, want MIT", want)
+	}
+	wantHeader, err := first.ClassifyHeader(context.Background(), header)
+	if err != nil {
+		t.Fatalf("ClassifyHeader() error = %v", err)
+	}
+
+	// A later scan is served from the cache, without loading the classifier
+	second := &CachingClassifier{Dir: dir}
+	got, err := second.Classify(context.Background(), licensePath)
+	if err != nil {
+		t.Fatalf("cached Classify() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cached Classify() = %+v, want %+v", got, want)
+	}
+	gotHeader, err := second.ClassifyHeader(context.Background(), header)
+	if err != nil {
+		t.Fatalf("cached ClassifyHeader() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotHeader, wantHeader) {
+		t.Errorf("cached ClassifyHeader() = %+v, want %+v", gotHeader, wantHeader)
+	}
+	if second.classifier != nil {
+		t.Error("classifier was loaded although every result was cached")
+	}
+
+	// Results depend on the classifier's settings
+	crossChecking := &CachingClassifier{Dir: dir, CrossCheck: true}
+	if crossChecking.key("license", []byte("MIT")) == second.key("license", []byte("MIT")) {
+		t.Error("cross-checking classifier shares cache keys with the default one")
+	}
+}
+
+func TestCachingClassifier_CorruptEntry(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	licensePath := filepath.Join("testdata", "replace", "fork", "LICENSE")
+	content, err := os.ReadFile(licensePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &CachingClassifier{Dir: dir}
+	path := c.path(c.key("license", content))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A corrupt entry is treated as a miss and replaced
+	licenses, err := c.Classify(context.Background(), licensePath)
+	if err != nil {
+		t.Fatalf("Classify() error = %v", err)
+	}
+	if len(licenses) != 1 || licenses[0].Name != "MIT" {
+		t.Errorf("Classify() = %+v, want MIT", licenses)
+	}
+	if _, ok := c.load(c.key("license", content)); !ok {
+		t.Error("corrupt entry was not replaced")
+	}
+}
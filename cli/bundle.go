@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/williammartin/licenseplease"
+)
+
+type BundleCmd struct {
+	ScanFlags `embed:""`
+
+	Out string `required:"" type:"path" help:"Directory to copy the license artifacts into."`
+}
+
+func (b *BundleCmd) Run(ctx context.Context) error {
+	opts, err := b.options()
+	if err != nil {
+		return err
+	}
+
+	result, err := licenseplease.Run(ctx, b.ProjectDir, opts...)
+	if err != nil {
+		return err
+	}
+
+	WriteReviewWarnings(os.Stderr, result)
+	copied, err := WriteBundle(b.Out, result)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "Copied %d files to %s\n", copied, b.Out)
+	return nil
+}
+
+// WriteBundle copies the artifacts that every license file requires to be
+// distributed into dir, laid out as <module>@<version>/<file>. It returns the
+// number of files copied.
+func WriteBundle(dir string, result *licenseplease.Result) (int, error) {
+	copied := make(map[string]bool)
+	for _, lf := range result.LicenseFiles {
+		artifacts, err := licenseArtifacts(lf)
+		if err != nil {
+			return 0, err
+		}
+		for _, artifact := range artifacts {
+			if !filepath.IsLocal(artifact) {
+				return 0, fmt.Errorf("artifact %s of %s is outside the module", artifact, lf.Module.Path)
+			}
+			dst := filepath.Join(dir, bundlePath(lf.Module), artifact)
+			if copied[dst] {
+				continue
+			}
+			if err := copyFile(filepath.Join(lf.Module.Dir, artifact), dst); err != nil {
+				return 0, err
+			}
+			copied[dst] = true
+		}
+	}
+	return len(copied), nil
+}
+
+// bundlePath returns the directory of a module's artifacts within a bundle.
+// The artifacts of a replaced module come from its replacement, so they are
+// laid out under the replacement's path and version, or under
+// <module>@local for a replacement by a local directory.
+func bundlePath(mod licenseplease.Module) string {
+	if mod.Replace != nil {
+		if mod.Replace.Version == "" {
+			return filepath.FromSlash(mod.Path + "@local")
+		}
+		return bundlePath(*mod.Replace)
+	}
+	if mod.Version == "" {
+		return filepath.FromSlash(mod.Path)
+	}
+	return filepath.FromSlash(mod.Path + "@" + mod.Version)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("reading artifact: %w", err)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("creating bundle directory: %w", err)
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("writing artifact: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("writing artifact %s: %w", dst, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("writing artifact %s: %w", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/williammartin/licenseplease"
+	"github.com/williammartin/licenseplease/cli"
+)
+
+func TestWriteBundle(t *testing.T) {
+	result := apacheResult(t)
+	modDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(modDir, "third_party"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(modDir, "LICENSE"), []byte("MIT License"), 0644)
+	os.WriteFile(filepath.Join(modDir, "third_party", "COPYING"), []byte("BSD License"), 0644)
+	mod := licenseplease.Module{Path: "github.com/test/mit", Version: "v0.1.0", Dir: modDir}
+	result.LicenseFiles = append(result.LicenseFiles,
+		licenseplease.LicenseFile{
+			Path:      filepath.Join(modDir, "LICENSE"),
+			RelPath:   "LICENSE",
+			Module:    mod,
+			Licenses:  []licenseplease.License{{Name: "MIT", Type: licenseplease.MIT{}}},
+			Artifacts: []string{"LICENSE"},
+		},
+		licenseplease.LicenseFile{
+			Path:      filepath.Join(modDir, "third_party", "COPYING"),
+			RelPath:   filepath.Join("third_party", "COPYING"),
+			Module:    mod,
+			Licenses:  []licenseplease.License{{Name: "BSD-3-Clause", Type: licenseplease.BSD3Clause{}}},
+			Artifacts: []string{filepath.Join("third_party", "COPYING")},
+		},
+	)
+
+	out := t.TempDir()
+	copied, err := cli.WriteBundle(out, result)
+	if err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+
+	want := map[string]string{
+		"github.com/test/apache@v1.2.3/LICENSE":          "Apache License\nVersion 2.0\n",
+		"github.com/test/apache@v1.2.3/NOTICE":           "Notice content\n",
+		"github.com/test/mit@v0.1.0/LICENSE":             "MIT License",
+		"github.com/test/mit@v0.1.0/third_party/COPYING": "BSD License",
+	}
+	if copied != len(want) {
+		t.Errorf("WriteBundle() copied %d files, want %d", copied, len(want))
+	}
+	for path, content := range want {
+		got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(path)))
+		if err != nil {
+			t.Errorf("bundle is missing %s: %v", path, err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", path, got, content)
+		}
+	}
+}
+
+func TestWriteBundle_ReplacedModules(t *testing.T) {
+	forkDir := t.TempDir()
+	localDir := t.TempDir()
+	os.WriteFile(filepath.Join(forkDir, "LICENSE"), []byte("Fork License"), 0644)
+	os.WriteFile(filepath.Join(localDir, "LICENSE"), []byte("Local License"), 0644)
+
+	licenseFile := func(mod licenseplease.Module) licenseplease.LicenseFile {
+		return licenseplease.LicenseFile{
+			Path:      filepath.Join(mod.Dir, "LICENSE"),
+			RelPath:   "LICENSE",
+			Module:    mod,
+			Licenses:  []licenseplease.License{{Name: "MIT", Type: licenseplease.MIT{}}},
+			Artifacts: []string{"LICENSE"},
+		}
+	}
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			licenseFile(licenseplease.Module{
+				Path: "github.com/test/forked", Version: "v1.0.5", Dir: forkDir,
+				Replace: &licenseplease.Module{Path: "github.com/fork/forked", Version: "v1.0.6", Dir: forkDir},
+			}),
+			licenseFile(licenseplease.Module{
+				Path: "github.com/test/local", Version: "v1.0.0", Dir: localDir,
+				Replace: &licenseplease.Module{Path: "../local", Dir: localDir},
+			}),
+		},
+	}
+
+	out := t.TempDir()
+	if _, err := cli.WriteBundle(out, result); err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+
+	// Files are laid out under the replacement they were copied from
+	want := map[string]string{
+		"github.com/fork/forked@v1.0.6/LICENSE": "Fork License",
+		"github.com/test/local@local/LICENSE":   "Local License",
+	}
+	for path, content := range want {
+		got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(path)))
+		if err != nil {
+			t.Errorf("bundle is missing %s: %v", path, err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", path, got, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "github.com", "test", "forked@v1.0.5")); !os.IsNotExist(err) {
+		t.Error("replaced module should not be bundled under its original version")
+	}
+}
+
+func TestWriteBundle_OutsideModule(t *testing.T) {
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				RelPath:   "LICENSE",
+				Module:    licenseplease.Module{Path: "github.com/test/evil", Version: "v1.0.0", Dir: t.TempDir()},
+				Artifacts: []string{"../../etc/passwd"},
+			},
+		},
+	}
+	if _, err := cli.WriteBundle(t.TempDir(), result); err == nil {
+		t.Error("WriteBundle() should refuse artifacts outside the module")
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -12,25 +13,249 @@ import (
 	"github.com/williammartin/licenseplease"
 )
 
+// Exit codes returned by the check command.
+const (
+	ExitViolation = 1 // A dependency violates the license policy
+	ExitError     = 2 // The tool itself failed
+)
+
 type CLI struct {
 	Report ReportCmd `cmd:"" help:"Generate a license report for a Go project."`
+	Check  CheckCmd  `cmd:"" help:"Check a Go project's dependencies against the license policy."`
+	Bundle BundleCmd `cmd:"" help:"Copy the license files and notices every dependency requires into a directory."`
+	Cache  CacheCmd  `cmd:"" help:"Manage the cache of license classification results."`
+}
+
+// ScanFlags are the flags shared by every command that scans a project.
+type ScanFlags struct {
+	ProjectDir    string   `arg:"" optional:"" default:"." help:"Path to Go project directory."`
+	Policy        string   `help:"Path to a license policy file. Defaults to .license-please.yaml in the project directory." type:"existingfile"`
+	Resolver      string   `enum:"graph,build" default:"graph" help:"How to resolve dependencies: graph includes every module in the module graph, build only modules linked into the build (${enum})."`
+	Binary        string   `help:"Resolve the modules embedded in a compiled Go binary instead of the project's source. Takes precedence over --resolver." type:"existingfile"`
+	GOOS          string   `name:"goos" help:"Target operating system to resolve build dependencies for. Requires --resolver=build."`
+	GOARCH        string   `name:"goarch" help:"Target architecture to resolve build dependencies for. Requires --resolver=build."`
+	Tags          []string `help:"Build tags to satisfy when resolving build dependencies. Requires --resolver=build."`
+	MinConfidence float64  `help:"Classifier confidence (0-1) below which a license match needs review. Overrides the policy's minConfidence."`
+	ScanHeaders   bool     `help:"Also scan source file headers for licenses that differ from their module's license."`
+	CrossCheck    bool     `help:"Also match the text of files with an SPDX-License-Identifier tag, flagging licenses the tag doesn't declare."`
+	Jobs          int      `help:"Number of modules to scan concurrently. Defaults to the number of CPUs."`
+	NoCache       bool     `help:"Classify every license file again instead of reusing cached results."`
+}
+
+func (f *ScanFlags) options() ([]licenseplease.Option, error) {
+	var opts []licenseplease.Option
+	if f.Policy != "" {
+		opts = append(opts, licenseplease.WithPolicyFile(f.Policy))
+	}
+	if f.MinConfidence != 0 {
+		opts = append(opts, licenseplease.WithMinConfidence(f.MinConfidence))
+	}
+	if f.ScanHeaders {
+		opts = append(opts, licenseplease.WithSourceHeaders())
+	}
+	if f.CrossCheck {
+		opts = append(opts, licenseplease.WithCrossCheck())
+	}
+	if f.Jobs < 0 {
+		return nil, errors.New("--jobs must not be negative")
+	}
+	if f.Jobs > 0 {
+		opts = append(opts, licenseplease.WithJobs(f.Jobs))
+	}
+	if !f.NoCache {
+		// Without a cache directory, scans still work, just more slowly
+		if dir, err := licenseplease.DefaultCacheDir(); err == nil {
+			opts = append(opts, licenseplease.WithCache(dir))
+		}
+	}
+
+	buildContext := f.GOOS != "" || f.GOARCH != "" || len(f.Tags) > 0
+	if buildContext && (f.Binary != "" || f.Resolver != "build") {
+		return nil, errors.New("--goos, --goarch and --tags require --resolver=build")
+	}
+
+	switch {
+	case f.Binary != "":
+		opts = append(opts, licenseplease.WithResolver(&licenseplease.BuildInfoResolver{Binary: f.Binary}))
+	case f.Resolver == "build":
+		opts = append(opts, licenseplease.WithResolver(&licenseplease.GoListResolver{
+			GOOS:   f.GOOS,
+			GOARCH: f.GOARCH,
+			Tags:   f.Tags,
+		}))
+	}
+	return opts, nil
 }
 
 type ReportCmd struct {
-	ProjectDir string `arg:"" optional:"" default:"." help:"Path to Go project directory."`
+	ScanFlags `embed:""`
+
+	Format      string `enum:"markdown,json,spdx,spdx-json,cyclonedx-json,cyclonedx-xml,notice" default:"markdown" help:"Output format (${enum})."`
+	IncludeText bool   `help:"Include full license texts in JSON output."`
+	DedupeTexts bool   `help:"Print each distinct license text once in markdown output, listing the modules it applies to."`
 }
 
 func (r *ReportCmd) Run(ctx context.Context) error {
-	result, err := licenseplease.Run(ctx, r.ProjectDir)
+	opts, err := r.options()
 	if err != nil {
 		return err
 	}
 
-	return WriteReport(os.Stdout, result)
+	result, err := licenseplease.Run(ctx, r.ProjectDir, opts...)
+	if err != nil {
+		return err
+	}
+
+	WriteReviewWarnings(os.Stderr, result)
+	switch r.Format {
+	case "json":
+		return WriteJSON(os.Stdout, result, r.IncludeText)
+	case "spdx":
+		return WriteSPDXTagValue(os.Stdout, result, projectName(r.ProjectDir))
+	case "spdx-json":
+		return WriteSPDXJSON(os.Stdout, result, projectName(r.ProjectDir))
+	case "cyclonedx-json":
+		return WriteCycloneDXJSON(os.Stdout, result, projectName(r.ProjectDir))
+	case "cyclonedx-xml":
+		return WriteCycloneDXXML(os.Stdout, result, projectName(r.ProjectDir))
+	case "notice":
+		return WriteNotice(os.Stdout, result)
+	default:
+		if r.DedupeTexts {
+			return WriteDedupedReport(os.Stdout, result)
+		}
+		return WriteReport(os.Stdout, result)
+	}
+}
+
+// projectName returns a human readable name for the project in dir.
+func projectName(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return filepath.Base(dir)
+}
+
+// WriteReviewWarnings writes a warning for each license the policy marked for review.
+func WriteReviewWarnings(w io.Writer, result *licenseplease.Result) {
+	if len(result.NeedsReview) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "warning: found %d dependencies with licenses that need review:\n", len(result.NeedsReview))
+	for _, v := range result.NeedsReview {
+		writeViolation(w, v)
+	}
+}
+
+// writeViolation writes a policy violation as an indented line, followed by
+// the excerpt of the license file if there is one.
+func writeViolation(w io.Writer, v licenseplease.PolicyViolation) {
+	fmt.Fprintf(w, "  %s\n", v)
+	if v.Excerpt == "" {
+		return
+	}
+	for _, line := range strings.Split(v.Excerpt, "\n") {
+		fmt.Fprintf(w, "      > %s\n", line)
+	}
+}
+
+type CheckCmd struct {
+	ScanFlags `embed:""`
 }
 
+func (c *CheckCmd) Run(ctx context.Context) error {
+	opts, err := c.options()
+	if err != nil {
+		return &exitError{err: err, code: ExitError}
+	}
+
+	result, err := licenseplease.Check(ctx, c.ProjectDir, opts...)
+	if err != nil {
+		return &exitError{err: err, code: ExitError}
+	}
+
+	WriteCheckSummary(os.Stdout, result)
+	if len(result.Violations) > 0 {
+		return &exitError{
+			err:  fmt.Errorf("found %d dependencies with disallowed licenses", len(result.Violations)),
+			code: ExitViolation,
+		}
+	}
+	return nil
+}
+
+// WriteCheckSummary writes a concise summary of a policy check to the given writer.
+func WriteCheckSummary(w io.Writer, result *licenseplease.Result) {
+	modules := make(map[string]bool)
+	for _, lf := range result.LicenseFiles {
+		modules[lf.Module.Path] = true
+	}
+	for _, mod := range result.Unlicensed {
+		modules[mod.Path] = true
+	}
+	fmt.Fprintf(w, "Checked %d license files across %d modules.\n", len(result.LicenseFiles), len(modules))
+	if len(result.Unlicensed) > 0 {
+		fmt.Fprintf(w, "No license found in %d modules.\n", len(result.Unlicensed))
+	}
+
+	if len(result.Violations) > 0 {
+		fmt.Fprintf(w, "\nDisallowed licenses (%d):\n", len(result.Violations))
+		for _, v := range result.Violations {
+			writeViolation(w, v)
+		}
+	}
+	if len(result.NeedsReview) > 0 {
+		fmt.Fprintf(w, "\nLicenses needing review (%d):\n", len(result.NeedsReview))
+		for _, v := range result.NeedsReview {
+			writeViolation(w, v)
+		}
+	}
+	if len(result.Violations) == 0 {
+		fmt.Fprintln(w, "\nAll dependencies comply with the license policy.")
+	}
+}
+
+type CacheCmd struct {
+	Clean CacheCleanCmd `cmd:"" help:"Remove all cached classification results."`
+}
+
+type CacheCleanCmd struct{}
+
+func (c *CacheCleanCmd) Run() error {
+	dir, err := licenseplease.DefaultCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("removing cache: %w", err)
+	}
+	fmt.Fprintf(os.Stdout, "Removed %s\n", dir)
+	return nil
+}
+
+// exitError is an error that makes the process exit with a specific code.
+type exitError struct {
+	err  error
+	code int
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+func (e *exitError) ExitCode() int { return e.code }
+
 // WriteReport writes the license report in markdown format to the given writer.
 func WriteReport(w io.Writer, result *licenseplease.Result) error {
+	return writeReport(w, result, false)
+}
+
+// WriteDedupedReport writes the license report in markdown format like
+// WriteReport, but prints each distinct license text once, followed by the
+// modules it applies to and their copyright statements.
+func WriteDedupedReport(w io.Writer, result *licenseplease.Result) error {
+	return writeReport(w, result, true)
+}
+
+func writeReport(w io.Writer, result *licenseplease.Result, dedupe bool) error {
 	// Header
 	fmt.Fprintln(w, "# Third-Party Licenses")
 	fmt.Fprintln(w)
@@ -45,12 +270,50 @@ func WriteReport(w io.Writer, result *licenseplease.Result) error {
 
 	for _, lf := range result.LicenseFiles {
 		names := licenseNames(lf)
-		url := lf.LicenseURL()
-		fmt.Fprintf(w, "| %s | %s | %s | [%s](%s) |\n",
-			lf.Module.Path, lf.Module.Version, names, lf.RelPath, url)
+		if len(reviewFindings(result, lf)) > 0 {
+			names += " (needs review)"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n",
+			lf.Module.Path, moduleVersion(lf.Module), names, sourceLink(lf))
 	}
 
 	fmt.Fprintln(w)
+
+	if len(result.Unlicensed) > 0 {
+		fmt.Fprintln(w, "## Unlicensed Modules")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "No license was found in the following modules. They may not be redistributed without permission from their authors.")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| Module | Version |")
+		fmt.Fprintln(w, "|--------|---------|")
+		for _, mod := range result.Unlicensed {
+			fmt.Fprintf(w, "| %s | %s |\n", mod.Path, moduleVersion(mod))
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(result.SourceHeaders) > 0 {
+		fmt.Fprintln(w, "## Source File Licenses")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "The following source files declare a license in their header that differs from their module's license.")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| Module | Version | File | License |")
+		fmt.Fprintln(w, "|--------|---------|------|---------|")
+		for _, h := range result.SourceHeaders {
+			fmt.Fprintf(w, "| %s | %s | %s | %s |\n", h.Module.Path, moduleVersion(h.Module), h.RelPath, licenseNames(h))
+		}
+		fmt.Fprintln(w)
+	}
+
+	if holders := licenseplease.CopyrightHolders(result.LicenseFiles); len(holders) > 0 {
+		fmt.Fprintln(w, "## Copyright Holders")
+		fmt.Fprintln(w)
+		for _, holder := range holders {
+			fmt.Fprintf(w, "- %s\n", holder)
+		}
+		fmt.Fprintln(w)
+	}
+
 	fmt.Fprintln(w, "---")
 	fmt.Fprintln(w)
 
@@ -58,12 +321,19 @@ func WriteReport(w io.Writer, result *licenseplease.Result) error {
 	fmt.Fprintln(w, "## License Texts")
 	fmt.Fprintln(w)
 
+	if dedupe {
+		return writeDedupedLicenseTexts(w, result)
+	}
+
 	for _, lf := range result.LicenseFiles {
 		names := licenseNames(lf)
 
 		fmt.Fprintf(w, "### %s %s\n\n", lf.Module.Path, lf.Module.Version)
 		fmt.Fprintf(w, "**License:** %s\n\n", names)
-		fmt.Fprintf(w, "**Source:** [%s](%s)\n\n", lf.RelPath, lf.LicenseURL())
+		fmt.Fprintf(w, "**Source:** %s\n\n", sourceLink(lf))
+		for _, detail := range licenseFileDetails(result, lf) {
+			fmt.Fprintf(w, "%s\n\n", detail)
+		}
 
 		content, err := os.ReadFile(lf.Path)
 		if err != nil {
@@ -82,30 +352,168 @@ func WriteReport(w io.Writer, result *licenseplease.Result) error {
 	return nil
 }
 
+// licenseFileDetails returns the lines describing a license file in the
+// report besides its license and source, such as "**Required by:** ...".
+func licenseFileDetails(result *licenseplease.Result, lf licenseplease.LicenseFile) []string {
+	var details []string
+	if lf.Module.Replace != nil {
+		details = append(details, fmt.Sprintf("**Replaced by:** %s", strings.TrimSpace(lf.Module.Replace.Path+" "+lf.Module.Replace.Version)))
+	}
+	if requiredBy := result.RequiredBy[lf.Module.Path]; len(requiredBy) > 0 {
+		details = append(details, fmt.Sprintf("**Required by:** %s", strings.Join(requiredBy, ", ")))
+	}
+	for _, l := range lf.Licenses {
+		if l.Waiver != nil {
+			details = append(details, fmt.Sprintf("**Exception:** %s waived: %s", l.Type.SPDX(), waiverDescription(l.Waiver)))
+		}
+	}
+	if match := matchDescription(lf); match != "" {
+		details = append(details, fmt.Sprintf("**Match:** %s", match))
+	}
+	for _, v := range reviewFindings(result, lf) {
+		details = append(details, fmt.Sprintf("**Needs review:** %s", v.Reason))
+	}
+	return details
+}
+
 func licenseNames(lf licenseplease.LicenseFile) string {
 	if len(lf.Licenses) == 0 {
 		// For NOTICE/COPYRIGHT files that aren't licenses, use the filename
-		base := strings.ToUpper(strings.TrimSuffix(lf.RelPath, filepath.Ext(lf.RelPath)))
-		if strings.Contains(base, "NOTICE") || strings.Contains(base, "COPYRIGHT") {
+		if lf.IsNotice() {
 			return "(NOTICE file)"
 		}
 		return "Unknown"
 	}
+	if lf.Elected != nil {
+		names := fmt.Sprintf("%s (elected %s)", lf.Expression(), lf.Elected)
+		for _, l := range lf.Licenses {
+			if l.Waiver != nil {
+				names += fmt.Sprintf(" (%s waived)", l.Name)
+			}
+		}
+		return names
+	}
 	names := make([]string, len(lf.Licenses))
 	for i, l := range lf.Licenses {
 		names[i] = l.Type.SPDX()
+		if l.Waiver != nil {
+			names[i] += " (waived)"
+		}
 	}
 	return strings.Join(names, ", ")
 }
 
+// plainLicenseNames returns the licenses of a license file like licenseNames,
+// but without marking waived licenses or the elected branch.
+func plainLicenseNames(lf licenseplease.LicenseFile) string {
+	if len(lf.Licenses) == 0 {
+		return licenseNames(lf)
+	}
+	if lf.Elected != nil {
+		return lf.Expression().String()
+	}
+	names := make([]string, len(lf.Licenses))
+	for i, l := range lf.Licenses {
+		names[i] = l.Type.SPDX()
+	}
+	return strings.Join(names, ", ")
+}
+
+// reviewFindings returns the findings needing review for a license file.
+func reviewFindings(result *licenseplease.Result, lf licenseplease.LicenseFile) []licenseplease.PolicyViolation {
+	var findings []licenseplease.PolicyViolation
+	for _, v := range result.NeedsReview {
+		if v.Module == lf.Module.Path && v.Version == lf.Module.Version && v.File == lf.RelPath {
+			findings = append(findings, v)
+		}
+	}
+	return findings
+}
+
+// matchDescription describes how well a license file matched its licenses,
+// e.g. "MIT (lines 5-21, 100% confidence); 98% of the file matched". It is
+// empty if the classifier didn't report any details.
+func matchDescription(lf licenseplease.LicenseFile) string {
+	var matches []string
+	for _, l := range lf.Licenses {
+		if l.Confidence == 0 {
+			continue
+		}
+		if l.Declared != "" {
+			matches = append(matches, fmt.Sprintf("%s (SPDX-License-Identifier on line %d)", l.Name, l.StartLine))
+			continue
+		}
+		matches = append(matches, fmt.Sprintf("%s (lines %d-%d, %.0f%% confidence)", l.Type.SPDX(), l.StartLine, l.EndLine, l.Confidence*100))
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	description := strings.Join(matches, ", ")
+	if lf.Coverage > 0 {
+		description += fmt.Sprintf("; %.0f%% of the file matched", lf.Coverage*100)
+	}
+	return description
+}
+
+// moduleVersion returns the module's version, followed by its replacement if
+// the module is replaced, e.g. "v1.0.5 => ../fork".
+func moduleVersion(m licenseplease.Module) string {
+	if m.Replace == nil {
+		return m.Version
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s => %s %s", m.Version, m.Replace.Path, m.Replace.Version))
+}
+
+// sourceLink returns a markdown link to the license file, or just its path
+// when it isn't published anywhere.
+func sourceLink(lf licenseplease.LicenseFile) string {
+	url := lf.LicenseURL()
+	if url == "" {
+		return lf.RelPath
+	}
+	return fmt.Sprintf("[%s](%s)", lf.RelPath, url)
+}
+
+func waiverDescription(e *licenseplease.Exception) string {
+	if e.Expires == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("%s (expires %s)", e.Reason, e.Expires)
+}
+
 func Execute() {
+	os.Exit(Main(os.Args[1:]))
+}
+
+// Main runs the command selected by args and returns the exit code for the
+// process. Invalid arguments are a failure of the tool, not a violation.
+func Main(args []string) int {
 	cli := &CLI{}
-	kctx := kong.Parse(cli,
+	parser, err := kong.New(cli,
 		kong.Name("license-please"),
 		kong.Description("A tool to help with Go OSS license compliance."),
 		kong.BindTo(context.Background(), (*context.Context)(nil)),
 	)
+	if err != nil {
+		panic(err)
+	}
 
-	err := kctx.Run(context.Background())
-	kctx.FatalIfErrorf(err)
+	kctx, err := parser.Parse(args)
+	if err != nil {
+		parser.Errorf("%s", err)
+		return ExitError
+	}
+	if err := kctx.Run(context.Background()); err != nil {
+		parser.Errorf("%s", err)
+		var exit *exitError
+		if errors.As(err, &exit) {
+			return exit.ExitCode()
+		}
+		var policyErr *licenseplease.PolicyError
+		if errors.As(err, &policyErr) {
+			return ExitViolation
+		}
+		return ExitError
+	}
+	return 0
 }
@@ -13,6 +13,32 @@ import (
 	"github.com/williammartin/licenseplease/cli"
 )
 
+// writeFiles writes files, keyed by their slash-separated path, under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+}
+
+// moduleLicenseFile returns the LICENSE file of module github.com/test/<name>,
+// whose directory is dir/<name>.
+func moduleLicenseFile(dir, name, version string, license licenseplease.License) licenseplease.LicenseFile {
+	modDir := filepath.Join(dir, name)
+	return licenseplease.LicenseFile{
+		Path:     filepath.Join(modDir, "LICENSE"),
+		RelPath:  "LICENSE",
+		Module:   licenseplease.Module{Path: "github.com/test/" + name, Version: version, Dir: modDir},
+		Licenses: []licenseplease.License{license},
+	}
+}
+
 func TestWriteReport_Format(t *testing.T) {
 	// Create temp file for license content
 	tmpDir := t.TempDir()
@@ -213,3 +239,401 @@ func TestE2E_CLIReport(t *testing.T) {
 
 	t.Logf("Report output length: %d bytes", len(output))
 }
+
+func TestMain_ExitCodes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "..", "testdata", "e2e")
+
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("allow:\n  - MIT\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"Compliant", []string{"check", "--no-cache", e2eDir}, 0},
+		{"Violation", []string{"check", "--no-cache", "--policy", policyPath, e2eDir}, cli.ExitViolation},
+		{"MissingPolicy", []string{"check", "--policy", "/nonexistent.yaml", e2eDir}, cli.ExitError},
+		{"UnknownFlag", []string{"check", "--bogus", e2eDir}, cli.ExitError},
+		{"InvalidFlag", []string{"check", "--jobs=-1", e2eDir}, cli.ExitError},
+		{"MissingProject", []string{"check", "--no-cache", filepath.Join(t.TempDir(), "missing")}, cli.ExitError},
+		{"ReportViolation", []string{"report", "--no-cache", "--policy", policyPath, e2eDir}, cli.ExitViolation},
+		{"ReportError", []string{"report", "--no-cache", filepath.Join(t.TempDir(), "missing")}, cli.ExitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cli.Main(tt.args); got != tt.want {
+				t.Errorf("Main(%q) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteReport_WaivedLicense(t *testing.T) {
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	os.WriteFile(licensePath, []byte("GNU GENERAL PUBLIC LICENSE"), 0644)
+
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:    licensePath,
+				RelPath: "LICENSE",
+				Module: licenseplease.Module{
+					Path:    "github.com/test/gpl",
+					Version: "v1.0.0",
+					Dir:     tmpDir,
+				},
+				Licenses: []licenseplease.License{
+					{
+						Name: "GPL-3.0",
+						Type: licenseplease.LicenseTypeFromSPDX("GPL-3.0"),
+						Waiver: &licenseplease.Exception{
+							Module:  "github.com/test/gpl",
+							Reason:  "Build tooling only",
+							Expires: "2030-01-01",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteReport(&buf, result); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "| github.com/test/gpl | v1.0.0 | GPL-3.0 (waived) |") {
+		t.Error("manifest should mark waived licenses")
+	}
+	if !strings.Contains(output, "**Exception:** GPL-3.0 waived: Build tooling only (expires 2030-01-01)") {
+		t.Error("license text section should include the exception reason")
+	}
+}
+
+func TestWriteReport_ReplacedModules(t *testing.T) {
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	os.WriteFile(licensePath, []byte("MIT License"), 0644)
+
+	mit := []licenseplease.License{{Name: "MIT", Type: licenseplease.MIT{}}}
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:    licensePath,
+				RelPath: "LICENSE",
+				Module: licenseplease.Module{
+					Path:    "github.com/test/forked",
+					Version: "v1.0.0",
+					Dir:     tmpDir,
+					Replace: &licenseplease.Module{Path: "github.com/me/forked", Version: "v1.0.1", Dir: tmpDir},
+				},
+				Licenses: mit,
+			},
+			{
+				Path:    licensePath,
+				RelPath: "LICENSE",
+				Module: licenseplease.Module{
+					Path:    "github.com/test/local",
+					Version: "v1.0.0",
+					Dir:     tmpDir,
+					Replace: &licenseplease.Module{Path: "../local", Dir: tmpDir},
+				},
+				Licenses: mit,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteReport(&buf, result); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "| github.com/test/forked | v1.0.0 => github.com/me/forked v1.0.1 | MIT | [LICENSE](https://pkg.go.dev/github.com/me/forked@v1.0.1?tab=licenses) |") {
+		t.Error("manifest should show the replacement and link to it")
+	}
+	if !strings.Contains(output, "| github.com/test/local | v1.0.0 => ../local | MIT | LICENSE |") {
+		t.Error("manifest should show local replacements without a link")
+	}
+	if !strings.Contains(output, "**Replaced by:** ../local") {
+		t.Error("license text section should show the replacement")
+	}
+}
+
+func TestWriteReport_Unlicensed(t *testing.T) {
+	result := &licenseplease.Result{
+		Unlicensed: []licenseplease.Module{
+			{Path: "github.com/test/nolicense", Version: "v0.1.0"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteReport(&buf, result); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "## Unlicensed Modules") {
+		t.Error("report should have a section for unlicensed modules")
+	}
+	if !strings.Contains(output, "| github.com/test/nolicense | v0.1.0 |") {
+		t.Error("report should list unlicensed modules")
+	}
+}
+
+func TestWriteReport_MatchDetails(t *testing.T) {
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	os.WriteFile(licensePath, []byte("MIT License"), 0644)
+
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:     licensePath,
+				RelPath:  "LICENSE",
+				Module:   licenseplease.Module{Path: "github.com/test/modified", Version: "v1.0.0", Dir: tmpDir},
+				Licenses: []licenseplease.License{{Name: "MIT", Type: licenseplease.MIT{}, Confidence: 1, StartLine: 5, EndLine: 21}},
+				Coverage: 0.85,
+			},
+			{
+				Path:     licensePath,
+				RelPath:  "LICENSE",
+				Module:   licenseplease.Module{Path: "github.com/test/tagged", Version: "v1.0.0", Dir: tmpDir},
+				Licenses: []licenseplease.License{{Name: "MIT", Type: licenseplease.MIT{}, Confidence: 1, StartLine: 3, EndLine: 3, Declared: "MIT"}},
+			},
+		},
+		NeedsReview: []licenseplease.PolicyViolation{
+			{Module: "github.com/test/modified", Version: "v1.0.0", License: "MIT", File: "LICENSE", Reason: "license text only partially matches (85% of the file)"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteReport(&buf, result); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	output := buf.String()
+	expected := []string{
+		"| github.com/test/modified | v1.0.0 | MIT (needs review) |",
+		"**Match:** MIT (lines 5-21, 100% confidence); 85% of the file matched",
+		"**Match:** MIT (SPDX-License-Identifier on line 3)",
+		"**Needs review:** license text only partially matches (85% of the file)",
+	}
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			t.Errorf("report missing %q", e)
+		}
+	}
+}
+
+func TestWriteReport_ElectedLicense(t *testing.T) {
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	os.WriteFile(licensePath, []byte("SPDX-License-Identifier: GPL-3.0-only OR Apache-2.0"), 0644)
+
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:    licensePath,
+				RelPath: "LICENSE",
+				Module:  licenseplease.Module{Path: "github.com/test/dual", Version: "v1.0.0", Dir: tmpDir},
+				Licenses: []licenseplease.License{
+					{Name: "GPL-3.0-only", Type: licenseplease.LicenseTypeFromSPDX("GPL-3.0-only"), Declared: "GPL-3.0-only OR Apache-2.0"},
+					{Name: "Apache-2.0", Type: licenseplease.Apache2{}, Declared: "GPL-3.0-only OR Apache-2.0"},
+				},
+				Elected: &licenseplease.Expression{License: "Apache-2.0"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteReport(&buf, result); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "| github.com/test/dual | v1.0.0 | GPL-3.0-only OR Apache-2.0 (elected Apache-2.0) |") {
+		t.Errorf("manifest should show the elected branch of the expression:\n%s", buf.String())
+	}
+}
+
+func TestWriteReport_ElectedWaivedLicense(t *testing.T) {
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	os.WriteFile(licensePath, []byte("SPDX-License-Identifier: GPL-3.0-only OR SSPL-1.0"), 0644)
+
+	waiver := &licenseplease.Exception{Module: "github.com/test/dual", Reason: "Build tooling only"}
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:    licensePath,
+				RelPath: "LICENSE",
+				Module:  licenseplease.Module{Path: "github.com/test/dual", Version: "v1.0.0", Dir: tmpDir},
+				Licenses: []licenseplease.License{
+					{Name: "GPL-3.0-only", Type: licenseplease.LicenseTypeFromSPDX("GPL-3.0-only"), Declared: "GPL-3.0-only OR SSPL-1.0", Waiver: waiver},
+					{Name: "SSPL-1.0", Type: licenseplease.LicenseTypeFromSPDX("SSPL-1.0"), Declared: "GPL-3.0-only OR SSPL-1.0"},
+				},
+				Elected: &licenseplease.Expression{License: "GPL-3.0-only"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteReport(&buf, result); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "| github.com/test/dual | v1.0.0 | GPL-3.0-only OR SSPL-1.0 (elected GPL-3.0-only) (GPL-3.0-only waived) |") {
+		t.Errorf("manifest should mark the waived license of an elected branch:\n%s", buf.String())
+	}
+}
+
+func TestWriteReport_CopyrightHolders(t *testing.T) {
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	os.WriteFile(licensePath, []byte("Copyright (c) 2020 Jane Doe"), 0644)
+
+	mit := []licenseplease.License{{Name: "MIT", Type: licenseplease.MIT{}}}
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:       licensePath,
+				RelPath:    "LICENSE",
+				Module:     licenseplease.Module{Path: "github.com/test/one", Version: "v1.0.0", Dir: tmpDir},
+				Licenses:   mit,
+				Copyrights: []licenseplease.Copyright{{Years: "2020", Holder: "Jane Doe"}},
+			},
+			{
+				Path:       licensePath,
+				RelPath:    "LICENSE",
+				Module:     licenseplease.Module{Path: "github.com/test/two", Version: "v1.0.0", Dir: tmpDir},
+				Licenses:   mit,
+				Copyrights: []licenseplease.Copyright{{Years: "2021", Holder: "jane doe"}, {Holder: "Acme Corp"}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteReport(&buf, result); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "## Copyright Holders\n\n- Acme Corp\n- Jane Doe\n\n") {
+		t.Errorf("report should list each copyright holder once:\n%s", buf.String())
+	}
+}
+
+func TestWriteReport_DeclaredException(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteReport(&buf, declaredResult(t, "Apache-2.0 WITH LLVM-exception")); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "| github.com/test/declared | v1.0.0 | Apache-2.0 WITH LLVM-exception |") {
+		t.Errorf("manifest should show the license with its exception:\n%s", buf.String())
+	}
+}
+
+func TestWriteReport_SourceHeaders(t *testing.T) {
+	result := &licenseplease.Result{
+		SourceHeaders: []licenseplease.LicenseFile{
+			{
+				Path:     "/tmp/mod/decode.go",
+				RelPath:  "decode.go",
+				Module:   licenseplease.Module{Path: "github.com/test/mixed", Version: "v1.0.0"},
+				Licenses: []licenseplease.License{{Name: "Apache-2.0", Type: licenseplease.Apache2{}}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteReport(&buf, result); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "## Source File Licenses") {
+		t.Error("report should have a section for source file licenses")
+	}
+	if !strings.Contains(output, "| github.com/test/mixed | v1.0.0 | decode.go | Apache-2.0 |") {
+		t.Error("report should list source files with differing licenses")
+	}
+}
+
+func TestWriteCheckSummary(t *testing.T) {
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{RelPath: "LICENSE", Module: licenseplease.Module{Path: "github.com/a/a", Version: "v1.0.0"}},
+			{RelPath: "NOTICE", Module: licenseplease.Module{Path: "github.com/a/a", Version: "v1.0.0"}},
+			{RelPath: "LICENSE", Module: licenseplease.Module{Path: "github.com/b/b", Version: "v1.0.0"}},
+		},
+		Violations: []licenseplease.PolicyViolation{
+			{Module: "github.com/b/b", Version: "v1.0.0", License: "GPL-3.0", File: "LICENSE", Reason: "license is denied by policy"},
+		},
+		NeedsReview: []licenseplease.PolicyViolation{
+			{Module: "github.com/a/a", Version: "v1.0.0", License: "MPL-2.0", File: "LICENSE", Reason: "license requires review"},
+		},
+	}
+
+	var buf bytes.Buffer
+	cli.WriteCheckSummary(&buf, result)
+	output := buf.String()
+
+	expected := []string{
+		"Checked 3 license files across 2 modules.",
+		"Disallowed licenses (1):\n  github.com/b/b@v1.0.0: GPL-3.0 (LICENSE): license is denied by policy",
+		"Licenses needing review (1):\n  github.com/a/a@v1.0.0: MPL-2.0 (LICENSE): license requires review",
+	}
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			t.Errorf("summary missing %q, got:\n%s", e, output)
+		}
+	}
+	if strings.Contains(output, "All dependencies comply") {
+		t.Error("summary should not claim compliance when there are violations")
+	}
+}
+
+func TestWriteCheckSummary_Excerpt(t *testing.T) {
+	result := &licenseplease.Result{
+		NeedsReview: []licenseplease.PolicyViolation{
+			{
+				Module:  "github.com/c/c",
+				Version: "v1.0.0",
+				License: licenseplease.NoAssertion,
+				File:    "LICENSE",
+				Reason:  "license could not be classified",
+				Excerpt: "Custom License\nOnly on Tuesdays.",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	cli.WriteCheckSummary(&buf, result)
+
+	expected := "  github.com/c/c@v1.0.0: NOASSERTION (LICENSE): license could not be classified\n      > Custom License\n      > Only on Tuesdays.\n"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("summary missing excerpt %q, got:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteCheckSummary_Compliant(t *testing.T) {
+	var buf bytes.Buffer
+	cli.WriteCheckSummary(&buf, &licenseplease.Result{})
+	if !strings.Contains(buf.String(), "All dependencies comply with the license policy.") {
+		t.Errorf("expected compliance message, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/williammartin/licenseplease"
+)
+
+const (
+	cyclonedxSpecVersion = "1.5"
+	cyclonedxXMLNS       = "http://cyclonedx.org/schema/bom/1.5"
+)
+
+type cdxBOM struct {
+	XMLName      xml.Name       `json:"-" xml:"bom"`
+	XMLNS        string         `json:"-" xml:"xmlns,attr"`
+	BOMFormat    string         `json:"bomFormat" xml:"-"`
+	SpecVersion  string         `json:"specVersion" xml:"-"`
+	SerialNumber string         `json:"serialNumber" xml:"serialNumber,attr"`
+	Version      int            `json:"version" xml:"version,attr"`
+	Metadata     cdxMetadata    `json:"metadata" xml:"metadata"`
+	Components   []cdxComponent `json:"components" xml:"components>component"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp" xml:"timestamp"`
+	Tools     cdxTools     `json:"tools" xml:"tools"`
+	Component cdxComponent `json:"component" xml:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components" xml:"components>component"`
+}
+
+type cdxComponent struct {
+	Type     string       `json:"type" xml:"type,attr"`
+	BOMRef   string       `json:"bom-ref,omitempty" xml:"bom-ref,attr,omitempty"`
+	Name     string       `json:"name" xml:"name"`
+	Version  string       `json:"version,omitempty" xml:"version,omitempty"`
+	Licenses cdxLicenses  `json:"licenses,omitempty" xml:"licenses,omitempty"`
+	PURL     string       `json:"purl,omitempty" xml:"purl,omitempty"`
+	Evidence *cdxEvidence `json:"evidence,omitempty" xml:"evidence,omitempty"`
+}
+
+// cdxLicenses is a list of licenses. Unlike a "licenses>license" tag, it
+// is omitted entirely from XML when empty.
+type cdxLicenses []cdxLicenseChoice
+
+func (l cdxLicenses) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, choice := range l {
+		if err := e.EncodeElement(choice, xml.StartElement{Name: xml.Name{Local: "license"}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// cdxLicenseChoice is either a license, wrapped as {"license": {...}} in JSON
+// while XML represents the same thing as a bare <license> element, or an SPDX
+// license expression.
+type cdxLicenseChoice struct {
+	License    *cdxLicense `json:"license,omitempty" xml:"-"`
+	Expression string      `json:"expression,omitempty" xml:"-"`
+}
+
+func (c cdxLicenseChoice) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if c.License == nil {
+		return e.EncodeElement(c.Expression, xml.StartElement{Name: xml.Name{Local: "expression"}})
+	}
+	return e.EncodeElement(c.License, start)
+}
+
+type cdxLicense struct {
+	ID   string `json:"id,omitempty" xml:"id,omitempty"`
+	Name string `json:"name,omitempty" xml:"name,omitempty"`
+}
+
+// cdxEvidence fields are in schema order, which matters for XML.
+type cdxEvidence struct {
+	Occurrences []cdxOccurrence `json:"occurrences,omitempty" xml:"occurrences>occurrence,omitempty"`
+	Licenses    cdxLicenses     `json:"licenses,omitempty" xml:"licenses,omitempty"`
+}
+
+type cdxOccurrence struct {
+	Location string `json:"location" xml:"location"`
+}
+
+// WriteCycloneDXJSON writes the license report as a CycloneDX 1.5 JSON BOM.
+// The name identifies the project the BOM describes.
+func WriteCycloneDXJSON(w io.Writer, result *licenseplease.Result, name string) error {
+	bom, err := newCycloneDXBOM(result, name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bom)
+}
+
+// WriteCycloneDXXML writes the license report as a CycloneDX 1.5 XML BOM.
+// The name identifies the project the BOM describes.
+func WriteCycloneDXXML(w io.Writer, result *licenseplease.Result, name string) error {
+	bom, err := newCycloneDXBOM(result, name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(bom); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// newCycloneDXBOM builds a BOM with one library component per module. The
+// license files each module's licenses were found in are recorded as evidence.
+func newCycloneDXBOM(result *licenseplease.Result, name string) (*cdxBOM, error) {
+	uuid, err := newUUID()
+	if err != nil {
+		return nil, fmt.Errorf("generating serial number: %w", err)
+	}
+
+	bom := &cdxBOM{
+		XMLNS:        cyclonedxXMLNS,
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  cyclonedxSpecVersion,
+		SerialNumber: "urn:uuid:" + uuid,
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools: cdxTools{
+				Components: []cdxComponent{{Type: "application", Name: "license-please"}},
+			},
+			Component: cdxComponent{Type: "application", Name: name},
+		},
+		Components: []cdxComponent{},
+	}
+
+	for _, group := range groupByModule(result.LicenseFiles) {
+		mod := group[0].Module
+		if isUnlicensed(result, mod) {
+			// Added with the other unlicensed modules below
+			continue
+		}
+		var licenses cdxLicenses
+		evidence := &cdxEvidence{}
+		for _, lf := range group {
+			evidence.Occurrences = append(evidence.Occurrences, cdxOccurrence{Location: lf.RelPath})
+			for _, l := range lf.Licenses {
+				license := cdxLicense{ID: l.Name}
+				if !licenseplease.IsSPDXLicenseID(l.Name) {
+					// Only identifiers from the SPDX license list are valid ids
+					license = cdxLicense{Name: l.Name}
+				}
+				if !slices.ContainsFunc(licenses, func(c cdxLicenseChoice) bool { return *c.License == license }) {
+					licenses = append(licenses, cdxLicenseChoice{License: &license})
+				}
+			}
+		}
+		// A list of licenses means all of them apply, and a license with an
+		// exception isn't a valid id. BOMs may only list licenses or give a
+		// single expression, not both
+		if ml := moduleLicense(result, mod); ml != nil && needsExpression(ml.Expression) {
+			licenses = cdxLicenses{{Expression: ml.Expression.String()}}
+		}
+		evidence.Licenses = licenses
+
+		purl := mod.PURL()
+		bom.Components = append(bom.Components, cdxComponent{
+			Type:     "library",
+			BOMRef:   purl,
+			Name:     mod.Path,
+			Version:  mod.Version,
+			Licenses: licenses,
+			PURL:     purl,
+			Evidence: evidence,
+		})
+	}
+
+	// Modules without a license file are still part of the bill of materials
+	for _, mod := range result.Unlicensed {
+		purl := mod.PURL()
+		bom.Components = append(bom.Components, cdxComponent{
+			Type:    "library",
+			BOMRef:  purl,
+			Name:    mod.Path,
+			Version: mod.Version,
+			PURL:    purl,
+		})
+	}
+
+	return bom, nil
+}
+
+// needsExpression reports whether expr can't be written as a list of
+// licenses, because it offers a choice of licenses or has an exception.
+func needsExpression(expr *licenseplease.Expression) bool {
+	switch expr.Op {
+	case "":
+		return expr.Exception != ""
+	case licenseplease.OpOr:
+		return true
+	}
+	return slices.ContainsFunc(expr.Operands, needsExpression)
+}
@@ -0,0 +1,203 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/williammartin/licenseplease/cli"
+)
+
+func TestWriteCycloneDXJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteCycloneDXJSON(&buf, spdxResult(t), "my-project"); err != nil {
+		t.Fatalf("WriteCycloneDXJSON() error = %v", err)
+	}
+
+	type license struct {
+		License struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"license"`
+	}
+	var bom struct {
+		BOMFormat    string `json:"bomFormat"`
+		SpecVersion  string `json:"specVersion"`
+		SerialNumber string `json:"serialNumber"`
+		Version      int    `json:"version"`
+		Metadata     struct {
+			Component struct {
+				Name string `json:"name"`
+			} `json:"component"`
+		} `json:"metadata"`
+		Components []struct {
+			Type     string    `json:"type"`
+			BOMRef   string    `json:"bom-ref"`
+			Name     string    `json:"name"`
+			Version  string    `json:"version"`
+			PURL     string    `json:"purl"`
+			Licenses []license `json:"licenses"`
+			Evidence struct {
+				Licenses    []license `json:"licenses"`
+				Occurrences []struct {
+					Location string `json:"location"`
+				} `json:"occurrences"`
+			} `json:"evidence"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.5" || bom.Version != 1 {
+		t.Errorf("unexpected BOM header: %q %q %d", bom.BOMFormat, bom.SpecVersion, bom.Version)
+	}
+	if !strings.HasPrefix(bom.SerialNumber, "urn:uuid:") {
+		t.Errorf("serialNumber = %q, want a urn:uuid", bom.SerialNumber)
+	}
+	if bom.Metadata.Component.Name != "my-project" {
+		t.Errorf("metadata component = %q, want my-project", bom.Metadata.Component.Name)
+	}
+	if len(bom.Components) != 4 {
+		t.Fatalf("expected one component per module (4), got %d", len(bom.Components))
+	}
+
+	for _, c := range bom.Components {
+		if c.Type != "library" || c.PURL == "" || c.BOMRef != c.PURL {
+			t.Errorf("unexpected component identity: %+v", c)
+		}
+		switch c.Name {
+		case "github.com/test/mit":
+			if c.PURL != "pkg:golang/github.com/test/mit@v1.0.0" {
+				t.Errorf("purl = %q", c.PURL)
+			}
+			if len(c.Licenses) != 1 || c.Licenses[0].License.ID != "MIT" {
+				t.Errorf("mit licenses = %+v, want MIT id", c.Licenses)
+			}
+			if len(c.Evidence.Licenses) != 1 || c.Evidence.Licenses[0].License.ID != "MIT" {
+				t.Errorf("mit evidence licenses = %+v, want MIT id", c.Evidence.Licenses)
+			}
+			var locations []string
+			for _, o := range c.Evidence.Occurrences {
+				locations = append(locations, o.Location)
+			}
+			if strings.Join(locations, ",") != "LICENSE,NOTICE" {
+				t.Errorf("mit evidence occurrences = %v, want [LICENSE NOTICE]", locations)
+			}
+		case "github.com/test/gpl":
+			if len(c.Licenses) != 1 || c.Licenses[0].License.ID != "GPL-3.0" || c.Licenses[0].License.Name != "" {
+				t.Errorf("gpl licenses = %+v, want GPL-3.0 id", c.Licenses)
+			}
+		case "github.com/test/custom":
+			// Licenses outside the SPDX list are recorded by name, not id
+			if len(c.Licenses) != 1 || c.Licenses[0].License.Name != "Custom" || c.Licenses[0].License.ID != "" {
+				t.Errorf("custom licenses = %+v, want name Custom", c.Licenses)
+			}
+		case "github.com/test/unknown":
+			if len(c.Licenses) != 0 {
+				t.Errorf("unclassified module should have no licenses, got %+v", c.Licenses)
+			}
+		}
+	}
+}
+
+func TestWriteCycloneDXXML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteCycloneDXXML(&buf, spdxResult(t), "my-project"); err != nil {
+		t.Fatalf("WriteCycloneDXXML() error = %v", err)
+	}
+
+	var bom struct {
+		XMLName      xml.Name
+		SerialNumber string `xml:"serialNumber,attr"`
+		Components   []struct {
+			Type       string   `xml:"type,attr"`
+			Name       string   `xml:"name"`
+			PURL       string   `xml:"purl"`
+			LicenseIDs []string `xml:"licenses>license>id"`
+			Locations  []string `xml:"evidence>occurrences>occurrence>location"`
+		} `xml:"components>component"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, buf.String())
+	}
+
+	if bom.XMLName.Space != "http://cyclonedx.org/schema/bom/1.5" || bom.XMLName.Local != "bom" {
+		t.Errorf("root element = %+v, want CycloneDX 1.5 bom", bom.XMLName)
+	}
+	if len(bom.Components) != 4 {
+		t.Fatalf("expected 4 components, got %d", len(bom.Components))
+	}
+	for _, c := range bom.Components {
+		if c.Name == "github.com/test/mit" {
+			if strings.Join(c.LicenseIDs, ",") != "MIT" {
+				t.Errorf("mit license ids = %v, want [MIT]", c.LicenseIDs)
+			}
+			if strings.Join(c.Locations, ",") != "LICENSE,NOTICE" {
+				t.Errorf("mit evidence locations = %v", c.Locations)
+			}
+		}
+	}
+	if strings.Contains(buf.String(), "<licenses></licenses>") {
+		t.Error("empty license lists should be omitted")
+	}
+}
+
+func TestWriteCycloneDXJSON_DeclaredException(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteCycloneDXJSON(&buf, declaredResult(t, "Apache-2.0 WITH LLVM-exception"), "my-project"); err != nil {
+		t.Fatalf("WriteCycloneDXJSON() error = %v", err)
+	}
+
+	var bom struct {
+		Components []struct {
+			Licenses []map[string]any `json:"licenses"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(bom.Components) != 1 {
+		t.Fatalf("expected 1 component, got %d", len(bom.Components))
+	}
+
+	// An exception isn't part of a license id, so it is written as an expression
+	want := []map[string]any{{"expression": "Apache-2.0 WITH LLVM-exception"}}
+	if got := bom.Components[0].Licenses; !reflect.DeepEqual(got, want) {
+		t.Errorf("licenses = %v, want %v", got, want)
+	}
+}
+
+func TestWriteCycloneDXJSON_Choice(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteCycloneDXJSON(&buf, declaredResult(t, "MIT OR Apache-2.0"), "my-project"); err != nil {
+		t.Fatalf("WriteCycloneDXJSON() error = %v", err)
+	}
+
+	var bom struct {
+		Components []struct {
+			Licenses []map[string]any `json:"licenses"`
+			Evidence struct {
+				Licenses []map[string]any `json:"licenses"`
+			} `json:"evidence"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(bom.Components) != 1 {
+		t.Fatalf("expected 1 component, got %d", len(bom.Components))
+	}
+
+	// Listing both licenses would mean both apply
+	want := []map[string]any{{"expression": "MIT OR Apache-2.0"}}
+	if got := bom.Components[0].Licenses; !reflect.DeepEqual(got, want) {
+		t.Errorf("licenses = %v, want %v", got, want)
+	}
+	if got := bom.Components[0].Evidence.Licenses; !reflect.DeepEqual(got, want) {
+		t.Errorf("evidence licenses = %v, want %v", got, want)
+	}
+}
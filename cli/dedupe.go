@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/williammartin/licenseplease"
+)
+
+// licenseText is a distinct license text and the license files that carry it.
+type licenseText struct {
+	// body is the text with its copyright statements removed.
+	body         string
+	licenseFiles []licenseplease.LicenseFile
+	// copyrights are the copyright statement lines of each license file.
+	copyrights [][]string
+}
+
+// writeDedupedLicenseTexts writes each distinct license text once, followed by
+// the modules it applies to. Copyright statements differ between otherwise
+// identical MIT and BSD licenses, so they are kept per module.
+func writeDedupedLicenseTexts(w io.Writer, result *licenseplease.Result) error {
+	texts, err := groupLicenseTexts(result.LicenseFiles)
+	if err != nil {
+		return err
+	}
+
+	for _, text := range texts {
+		// Waivers and elected branches are specific to each module, so they
+		// are only shown in the module's entry
+		heading := plainLicenseNames(text.licenseFiles[0])
+		fmt.Fprintf(w, "### %s\n\n", heading)
+		fmt.Fprintln(w, "**Applies to:**")
+		fmt.Fprintln(w)
+		for i, lf := range text.licenseFiles {
+			fmt.Fprintf(w, "- %s %s (%s)\n", lf.Module.Path, lf.Module.Version, sourceLink(lf))
+			if names := licenseNames(lf); names != heading {
+				fmt.Fprintf(w, "  - **License:** %s\n", names)
+			}
+			for _, line := range text.copyrights[i] {
+				fmt.Fprintf(w, "  - %s\n", line)
+			}
+			for _, detail := range licenseFileDetails(result, lf) {
+				fmt.Fprintf(w, "  - %s\n", detail)
+			}
+		}
+		fmt.Fprintln(w)
+
+		if text.body != "" {
+			fmt.Fprintln(w, "```")
+			fmt.Fprintln(w, text.body)
+			fmt.Fprintln(w, "```")
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+// groupLicenseTexts groups license files by the hash of their normalized text,
+// in the order the texts were first found. Texts are normalized by removing
+// copyright statements and collapsing whitespace, so that reflowed or
+// re-indented copies of a license are grouped together.
+func groupLicenseTexts(licenseFiles []licenseplease.LicenseFile) ([]*licenseText, error) {
+	var texts []*licenseText
+	byHash := make(map[[sha256.Size]byte]*licenseText)
+	for _, lf := range licenseFiles {
+		content, err := os.ReadFile(lf.Path)
+		if err != nil {
+			return nil, fmt.Errorf("reading license file %s: %w", lf.Path, err)
+		}
+		body, copyrights := splitCopyrights(string(content))
+
+		hash := sha256.Sum256([]byte(strings.Join(strings.Fields(body), " ")))
+		text, ok := byHash[hash]
+		if !ok {
+			text = &licenseText{body: body}
+			byHash[hash] = text
+			texts = append(texts, text)
+		}
+		text.licenseFiles = append(text.licenseFiles, lf)
+		text.copyrights = append(text.copyrights, copyrights)
+	}
+	return texts, nil
+}
+
+// splitCopyrights separates the copyright statement lines of a license text
+// from the rest of it.
+func splitCopyrights(content string) (body string, copyrights []string) {
+	var lines []string
+	removed := false
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if licenseplease.IsCopyrightStatement(line) {
+			copyrights = append(copyrights, strings.TrimSpace(line))
+			removed = true
+			continue
+		}
+		line = strings.TrimRight(line, " \t")
+		// Don't leave a double blank line where the statements were
+		if removed && line == "" && len(lines) > 0 && lines[len(lines)-1] == "" {
+			continue
+		}
+		removed = false
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), copyrights
+}
@@ -0,0 +1,110 @@
+package cli_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/williammartin/licenseplease"
+	"github.com/williammartin/licenseplease/cli"
+)
+
+func TestWriteDedupedReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	mitBody := "Permission is hereby granted, free of charge, to any person obtaining a copy\nof this software."
+	files := map[string]string{
+		"a/LICENSE": "MIT License\n\nCopyright (c) 2020 Jane Doe\n\n" + mitBody + "\n",
+		"b/LICENSE": "MIT License\r\n\r\nCopyright (c) 2021 John Doe\r\n\r\n" + strings.ReplaceAll(mitBody, "\n", " ") + "  \r\n",
+		"c/LICENSE": "Apache License\nVersion 2.0, January 2004\n",
+		"d/LICENSE": "Apache License\nVersion 2.0, January 2004\n",
+	}
+	writeFiles(t, tmpDir, files)
+
+	mit := licenseplease.License{Name: "MIT", Type: licenseplease.MIT{}}
+	apache := licenseplease.License{Name: "Apache-2.0", Type: licenseplease.Apache2{}}
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			moduleLicenseFile(tmpDir, "a", "v1.0.0", mit),
+			moduleLicenseFile(tmpDir, "c", "v1.0.0", apache),
+			moduleLicenseFile(tmpDir, "b", "v1.0.0", mit),
+			moduleLicenseFile(tmpDir, "d", "v1.0.0", apache),
+		},
+		NeedsReview: []licenseplease.PolicyViolation{
+			{Module: "github.com/test/d", Version: "v1.0.0", File: "LICENSE", Reason: "license text only partially matches"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteDedupedReport(&buf, result); err != nil {
+		t.Fatalf("WriteDedupedReport() error = %v", err)
+	}
+	output := buf.String()
+	texts := output[strings.Index(output, "## License Texts"):]
+
+	// Texts that only differ in copyright statements and whitespace are printed once
+	if n := strings.Count(texts, "Permission is hereby granted"); n != 1 {
+		t.Errorf("MIT text printed %d times, want once:\n%s", n, texts)
+	}
+	if n := strings.Count(texts, "Version 2.0, January 2004"); n != 1 {
+		t.Errorf("Apache text printed %d times, want once:\n%s", n, texts)
+	}
+
+	// Copyright statements and per-file details are kept for each module
+	for _, want := range []string{
+		"tab=licenses))\n  - Copyright (c) 2020 Jane Doe\n- github.com/test/b v1.0.0 ",
+		"tab=licenses))\n  - Copyright (c) 2021 John Doe\n\n",
+		"- github.com/test/d v1.0.0 ([LICENSE](https://pkg.go.dev/github.com/test/d@v1.0.0?tab=licenses))\n  - **Needs review:** license text only partially matches\n",
+	} {
+		if !strings.Contains(texts, want) {
+			t.Errorf("license texts missing %q:\n%s", want, texts)
+		}
+	}
+
+	if !strings.Contains(texts, "```\nMIT License\n\nPermission is hereby granted") {
+		t.Errorf("copyright statements should be removed from the shared text:\n%s", texts)
+	}
+
+	// Texts are printed in the order they were first found
+	if strings.Index(texts, "### MIT") > strings.Index(texts, "### Apache-2.0") {
+		t.Errorf("MIT text should come before Apache-2.0:\n%s", texts)
+	}
+
+	// The manifest still lists every module
+	if !strings.Contains(output, "| github.com/test/b | v1.0.0 | MIT |") {
+		t.Errorf("manifest should list every module:\n%s", output)
+	}
+}
+
+func TestWriteDedupedReport_Waiver(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"a/LICENSE": "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n",
+		"b/LICENSE": "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n",
+	})
+
+	waived := moduleLicenseFile(tmpDir, "a", "v1.0.0", licenseplease.License{
+		Name:   "GPL-3.0",
+		Type:   licenseplease.LicenseTypeFromSPDX("GPL-3.0"),
+		Waiver: &licenseplease.Exception{Module: "github.com/test/a", Reason: "Build tooling only"},
+	})
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			waived,
+			moduleLicenseFile(tmpDir, "b", "v1.0.0", licenseplease.License{Name: "GPL-3.0", Type: licenseplease.LicenseTypeFromSPDX("GPL-3.0")}),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteDedupedReport(&buf, result); err != nil {
+		t.Fatalf("WriteDedupedReport() error = %v", err)
+	}
+	texts := buf.String()[strings.Index(buf.String(), "## License Texts"):]
+
+	// One module's waiver doesn't apply to every module sharing the text
+	if !strings.Contains(texts, "### GPL-3.0\n") {
+		t.Errorf("heading should only name the license:\n%s", texts)
+	}
+	if !strings.Contains(texts, "tab=licenses))\n  - **License:** GPL-3.0 (waived)\n  - **Exception:** GPL-3.0 waived: Build tooling only\n- github.com/test/b v1.0.0 ") {
+		t.Errorf("waiver should be shown for its module only:\n%s", texts)
+	}
+}
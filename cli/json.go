@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/williammartin/licenseplease"
+)
+
+// JSONSchemaVersion is the version of the JSON report schema. It changes
+// whenever a field is removed or changes meaning; new fields may be added
+// without bumping it.
+const JSONSchemaVersion = 1
+
+type jsonReport struct {
+	SchemaVersion    int                 `json:"schemaVersion"`
+	LicenseFiles     []jsonLicenseFile   `json:"licenseFiles"`
+	Unlicensed       []jsonModule        `json:"unlicensed"`
+	SourceHeaders    []jsonSourceFile    `json:"sourceHeaders"`
+	Violations       []jsonViolation     `json:"violations"`
+	NeedsReview      []jsonViolation     `json:"needsReview"`
+	Modules          []jsonModuleLicense `json:"modules"`
+	CopyrightHolders []string            `json:"copyrightHolders"`
+}
+
+type jsonLicenseFile struct {
+	Module     string          `json:"module"`
+	Version    string          `json:"version"`
+	Path       string          `json:"path"`
+	RequiredBy []string        `json:"requiredBy,omitempty"`
+	Replace    *jsonReplace    `json:"replace,omitempty"`
+	Licenses   []jsonLicense   `json:"licenses"`
+	Expression string          `json:"expression,omitempty"`
+	Elected    string          `json:"elected,omitempty"`
+	Coverage   float64         `json:"coverage,omitempty"`
+	Artifacts  []string        `json:"artifacts"`
+	Copyrights []jsonCopyright `json:"copyrights,omitempty"`
+	Text       string          `json:"text,omitempty"`
+}
+
+type jsonCopyright struct {
+	Years  string `json:"years,omitempty"`
+	Holder string `json:"holder"`
+}
+
+type jsonSourceFile struct {
+	Module   string        `json:"module"`
+	Version  string        `json:"version"`
+	Path     string        `json:"path"`
+	Licenses []jsonLicense `json:"licenses"`
+}
+
+type jsonModule struct {
+	Module     string       `json:"module"`
+	Version    string       `json:"version"`
+	RequiredBy []string     `json:"requiredBy,omitempty"`
+	Replace    *jsonReplace `json:"replace,omitempty"`
+}
+
+type jsonModuleLicense struct {
+	Module     string `json:"module"`
+	Version    string `json:"version"`
+	Expression string `json:"expression"`
+	Elected    string `json:"elected,omitempty"`
+}
+
+type jsonReplace struct {
+	Module  string `json:"module"`
+	Version string `json:"version,omitempty"`
+}
+
+type jsonLicense struct {
+	SPDX       string      `json:"spdx"`
+	Confidence float64     `json:"confidence,omitempty"`
+	StartLine  int         `json:"startLine,omitempty"`
+	EndLine    int         `json:"endLine,omitempty"`
+	Waiver     *jsonWaiver `json:"waiver,omitempty"`
+}
+
+type jsonWaiver struct {
+	Reason  string `json:"reason"`
+	Expires string `json:"expires,omitempty"`
+}
+
+type jsonViolation struct {
+	Module  string `json:"module"`
+	Version string `json:"version"`
+	License string `json:"license"`
+	File    string `json:"file"`
+	Reason  string `json:"reason"`
+	Excerpt string `json:"excerpt,omitempty"`
+}
+
+// WriteJSON writes the license report as JSON to the given writer. When
+// includeText is set, each license file's full text is included.
+func WriteJSON(w io.Writer, result *licenseplease.Result, includeText bool) error {
+	report := jsonReport{
+		SchemaVersion:    JSONSchemaVersion,
+		LicenseFiles:     []jsonLicenseFile{},
+		Unlicensed:       []jsonModule{},
+		SourceHeaders:    []jsonSourceFile{},
+		Violations:       jsonViolations(result.Violations),
+		NeedsReview:      jsonViolations(result.NeedsReview),
+		Modules:          []jsonModuleLicense{},
+		CopyrightHolders: licenseplease.CopyrightHolders(result.LicenseFiles),
+	}
+	if report.CopyrightHolders == nil {
+		report.CopyrightHolders = []string{}
+	}
+
+	for _, lf := range result.LicenseFiles {
+		artifacts, err := licenseArtifacts(lf)
+		if err != nil {
+			return err
+		}
+
+		entry := jsonLicenseFile{
+			Module:     lf.Module.Path,
+			Version:    lf.Module.Version,
+			Path:       lf.RelPath,
+			RequiredBy: result.RequiredBy[lf.Module.Path],
+			Replace:    jsonReplaceOf(lf.Module),
+			Licenses:   jsonLicenses(lf.Licenses),
+			Coverage:   lf.Coverage,
+			Artifacts:  artifacts,
+		}
+		if expr := lf.Expression(); expr != nil {
+			entry.Expression = expr.String()
+		}
+		if lf.Elected != nil {
+			entry.Elected = lf.Elected.String()
+		}
+		for _, c := range lf.Copyrights {
+			entry.Copyrights = append(entry.Copyrights, jsonCopyright{Years: c.Years, Holder: c.Holder})
+		}
+		if includeText {
+			content, err := os.ReadFile(lf.Path)
+			if err != nil {
+				return fmt.Errorf("reading license file %s: %w", lf.Path, err)
+			}
+			entry.Text = string(content)
+		}
+		report.LicenseFiles = append(report.LicenseFiles, entry)
+	}
+
+	for _, ml := range result.Modules {
+		entry := jsonModuleLicense{
+			Module:     ml.Module.Path,
+			Version:    ml.Module.Version,
+			Expression: ml.Expression.String(),
+		}
+		if ml.Elected != nil {
+			entry.Elected = ml.Elected.String()
+		}
+		report.Modules = append(report.Modules, entry)
+	}
+
+	for _, h := range result.SourceHeaders {
+		report.SourceHeaders = append(report.SourceHeaders, jsonSourceFile{
+			Module:   h.Module.Path,
+			Version:  h.Module.Version,
+			Path:     h.RelPath,
+			Licenses: jsonLicenses(h.Licenses),
+		})
+	}
+
+	for _, mod := range result.Unlicensed {
+		report.Unlicensed = append(report.Unlicensed, jsonModule{
+			Module:     mod.Path,
+			Version:    mod.Version,
+			RequiredBy: result.RequiredBy[mod.Path],
+			Replace:    jsonReplaceOf(mod),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func jsonLicenses(licenses []licenseplease.License) []jsonLicense {
+	out := []jsonLicense{}
+	for _, l := range licenses {
+		license := jsonLicense{
+			SPDX:       l.Type.SPDX(),
+			Confidence: l.Confidence,
+			StartLine:  l.StartLine,
+			EndLine:    l.EndLine,
+		}
+		if l.Waiver != nil {
+			license.Waiver = &jsonWaiver{Reason: l.Waiver.Reason, Expires: l.Waiver.Expires}
+		}
+		out = append(out, license)
+	}
+	return out
+}
+
+func jsonReplaceOf(mod licenseplease.Module) *jsonReplace {
+	if mod.Replace == nil {
+		return nil
+	}
+	return &jsonReplace{Module: mod.Replace.Path, Version: mod.Replace.Version}
+}
+
+// licenseArtifacts returns the artifacts the aggregator collected for the
+// license file, collecting them now for license files built by hand.
+func licenseArtifacts(lf licenseplease.LicenseFile) ([]string, error) {
+	if lf.Artifacts != nil {
+		return lf.Artifacts, nil
+	}
+	return lf.CollectArtifacts()
+}
+
+func jsonViolations(violations []licenseplease.PolicyViolation) []jsonViolation {
+	out := []jsonViolation{}
+	for _, v := range violations {
+		out = append(out, jsonViolation{
+			Module:  v.Module,
+			Version: v.Version,
+			License: v.License,
+			File:    v.File,
+			Reason:  v.Reason,
+			Excerpt: v.Excerpt,
+		})
+	}
+	return out
+}
@@ -0,0 +1,330 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/williammartin/licenseplease"
+	"github.com/williammartin/licenseplease/cli"
+)
+
+type jsonReport struct {
+	SchemaVersion int `json:"schemaVersion"`
+	LicenseFiles  []struct {
+		Module  string `json:"module"`
+		Version string `json:"version"`
+		Path    string `json:"path"`
+		Replace *struct {
+			Module  string `json:"module"`
+			Version string `json:"version"`
+		} `json:"replace"`
+		Licenses []struct {
+			SPDX   string `json:"spdx"`
+			Waiver *struct {
+				Reason string `json:"reason"`
+			} `json:"waiver"`
+		} `json:"licenses"`
+		Artifacts []string `json:"artifacts"`
+		Text      string   `json:"text"`
+	} `json:"licenseFiles"`
+	NeedsReview []struct {
+		Module string `json:"module"`
+		Reason string `json:"reason"`
+	} `json:"needsReview"`
+}
+
+func apacheResult(t *testing.T) *licenseplease.Result {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	if err := os.WriteFile(licensePath, []byte("Apache License\nVersion 2.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "NOTICE"), []byte("Notice content\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:    licensePath,
+				RelPath: "LICENSE",
+				Module: licenseplease.Module{
+					Path:    "github.com/test/apache",
+					Version: "v1.2.3",
+					Dir:     tmpDir,
+				},
+				Licenses: []licenseplease.License{
+					{Name: "Apache-2.0", Type: licenseplease.Apache2{}},
+				},
+			},
+		},
+		NeedsReview: []licenseplease.PolicyViolation{
+			{Module: "github.com/test/apache", Version: "v1.2.3", License: "Apache-2.0", File: "LICENSE", Reason: "license requires review"},
+		},
+	}
+}
+
+// declaredResult returns a result for a module whose license file is tagged
+// with the given SPDX-License-Identifier, classified as Check would.
+func declaredResult(t *testing.T, tag string) *licenseplease.Result {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	if err := os.WriteFile(licensePath, []byte("SPDX-License-Identifier: "+tag+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	classifier, err := licenseplease.NewGoogleLicenseClassifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	licenses, err := classifier.Classify(context.Background(), licensePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	licenseFiles := []licenseplease.LicenseFile{
+		{
+			Path:     licensePath,
+			RelPath:  "LICENSE",
+			Module:   licenseplease.Module{Path: "github.com/test/declared", Version: "v1.0.0", Dir: tmpDir},
+			Licenses: licenses,
+		},
+	}
+	return &licenseplease.Result{LicenseFiles: licenseFiles, Modules: licenseplease.ModuleLicenses(licenseFiles)}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteJSON(&buf, apacheResult(t), false); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	if report.SchemaVersion != cli.JSONSchemaVersion {
+		t.Errorf("schemaVersion = %d, want %d", report.SchemaVersion, cli.JSONSchemaVersion)
+	}
+	if len(report.LicenseFiles) != 1 {
+		t.Fatalf("expected 1 license file, got %d", len(report.LicenseFiles))
+	}
+
+	lf := report.LicenseFiles[0]
+	if lf.Module != "github.com/test/apache" || lf.Version != "v1.2.3" || lf.Path != "LICENSE" {
+		t.Errorf("unexpected license file: %+v", lf)
+	}
+	if len(lf.Licenses) != 1 || lf.Licenses[0].SPDX != "Apache-2.0" {
+		t.Errorf("unexpected licenses: %+v", lf.Licenses)
+	}
+	if !slices.Equal(lf.Artifacts, []string{"LICENSE", "NOTICE"}) {
+		t.Errorf("artifacts = %v, want [LICENSE NOTICE]", lf.Artifacts)
+	}
+	if lf.Text != "" {
+		t.Error("text should be omitted unless requested")
+	}
+	if lf.Replace != nil {
+		t.Errorf("replace should be omitted for modules that aren't replaced, got %+v", lf.Replace)
+	}
+	if len(report.NeedsReview) != 1 || report.NeedsReview[0].Reason != "license requires review" {
+		t.Errorf("unexpected needsReview: %+v", report.NeedsReview)
+	}
+}
+
+func TestWriteJSON_IncludeText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteJSON(&buf, apacheResult(t), true); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if got := report.LicenseFiles[0].Text; got != "Apache License\nVersion 2.0\n" {
+		t.Errorf("text = %q, want license content", got)
+	}
+}
+
+func TestWriteJSON_Replace(t *testing.T) {
+	result := apacheResult(t)
+	result.LicenseFiles[0].Module.Replace = &licenseplease.Module{Path: "../apache"}
+
+	var buf bytes.Buffer
+	if err := cli.WriteJSON(&buf, result, false); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	replace := report.LicenseFiles[0].Replace
+	if replace == nil || replace.Module != "../apache" || replace.Version != "" {
+		t.Errorf("replace = %+v, want ../apache", replace)
+	}
+}
+
+func TestWriteJSON_Unlicensed(t *testing.T) {
+	result := &licenseplease.Result{
+		Unlicensed: []licenseplease.Module{{Path: "github.com/test/nolicense", Version: "v0.1.0"}},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteJSON(&buf, result, false); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var report struct {
+		Unlicensed []struct {
+			Module  string `json:"module"`
+			Version string `json:"version"`
+		} `json:"unlicensed"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(report.Unlicensed) != 1 || report.Unlicensed[0].Module != "github.com/test/nolicense" || report.Unlicensed[0].Version != "v0.1.0" {
+		t.Errorf("unlicensed = %+v, want github.com/test/nolicense v0.1.0", report.Unlicensed)
+	}
+}
+
+func TestWriteJSON_Expressions(t *testing.T) {
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	os.WriteFile(licensePath, []byte("SPDX-License-Identifier: MIT OR Apache-2.0"), 0644)
+
+	mod := licenseplease.Module{Path: "github.com/test/dual", Version: "v1.0.0", Dir: tmpDir}
+	expr, err := licenseplease.ParseExpression("MIT OR Apache-2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:    licensePath,
+				RelPath: "LICENSE",
+				Module:  mod,
+				Licenses: []licenseplease.License{
+					{Name: "MIT", Type: licenseplease.MIT{}, Declared: "MIT OR Apache-2.0"},
+					{Name: "Apache-2.0", Type: licenseplease.Apache2{}, Declared: "MIT OR Apache-2.0"},
+				},
+				Elected: expr.Operands[0],
+			},
+		},
+		Modules: []licenseplease.ModuleLicense{{Module: mod, Expression: expr, Elected: expr.Operands[0]}},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteJSON(&buf, result, false); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var report struct {
+		LicenseFiles []struct {
+			Expression string `json:"expression"`
+			Elected    string `json:"elected"`
+		} `json:"licenseFiles"`
+		Modules []struct {
+			Module     string `json:"module"`
+			Version    string `json:"version"`
+			Expression string `json:"expression"`
+			Elected    string `json:"elected"`
+		} `json:"modules"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(report.LicenseFiles) != 1 || report.LicenseFiles[0].Expression != "MIT OR Apache-2.0" || report.LicenseFiles[0].Elected != "MIT" {
+		t.Errorf("licenseFiles = %+v, want MIT elected from MIT OR Apache-2.0", report.LicenseFiles)
+	}
+	if len(report.Modules) != 1 || report.Modules[0].Module != "github.com/test/dual" || report.Modules[0].Expression != "MIT OR Apache-2.0" || report.Modules[0].Elected != "MIT" {
+		t.Errorf("modules = %+v, want github.com/test/dual with MIT elected", report.Modules)
+	}
+}
+
+func TestWriteJSON_Copyrights(t *testing.T) {
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	os.WriteFile(licensePath, []byte("Copyright (c) 2020 Jane Doe\nCopyright (c) The Authors"), 0644)
+
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:       licensePath,
+				RelPath:    "LICENSE",
+				Module:     licenseplease.Module{Path: "github.com/test/mit", Version: "v1.0.0", Dir: tmpDir},
+				Licenses:   []licenseplease.License{{Name: "MIT", Type: licenseplease.MIT{}}},
+				Copyrights: []licenseplease.Copyright{{Years: "2020", Holder: "Jane Doe"}, {Holder: "The Authors"}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteJSON(&buf, result, false); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var report struct {
+		LicenseFiles []struct {
+			Copyrights []map[string]string `json:"copyrights"`
+		} `json:"licenseFiles"`
+		CopyrightHolders []string `json:"copyrightHolders"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	wantCopyrights := []map[string]string{{"years": "2020", "holder": "Jane Doe"}, {"holder": "The Authors"}}
+	if len(report.LicenseFiles) != 1 || !reflect.DeepEqual(report.LicenseFiles[0].Copyrights, wantCopyrights) {
+		t.Errorf("licenseFiles = %+v, want copyrights %v", report.LicenseFiles, wantCopyrights)
+	}
+	if want := []string{"Jane Doe", "The Authors"}; !reflect.DeepEqual(report.CopyrightHolders, want) {
+		t.Errorf("copyrightHolders = %v, want %v", report.CopyrightHolders, want)
+	}
+}
+
+func TestWriteJSON_DeclaredException(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteJSON(&buf, declaredResult(t, "Apache-2.0 WITH LLVM-exception"), false); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(report.LicenseFiles) != 1 || len(report.LicenseFiles[0].Licenses) != 1 {
+		t.Fatalf("expected 1 license file with 1 license, got %+v", report.LicenseFiles)
+	}
+	if spdx := report.LicenseFiles[0].Licenses[0].SPDX; spdx != "Apache-2.0 WITH LLVM-exception" {
+		t.Errorf("spdx = %q, want the license with its exception", spdx)
+	}
+}
+
+func TestWriteJSON_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteJSON(&buf, &licenseplease.Result{}, false); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	// Empty lists are written as [] rather than null so consumers can rely on their type
+	var raw map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &raw); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	for _, key := range []string{"licenseFiles", "unlicensed", "sourceHeaders", "violations", "needsReview", "modules", "copyrightHolders"} {
+		if _, ok := raw[key].([]any); !ok {
+			t.Errorf("%s = %v, want an empty array", key, raw[key])
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/williammartin/licenseplease"
+)
+
+// noticeSeparator separates the notices of different modules.
+var noticeSeparator = strings.Repeat("=", 80)
+
+// notice is the text of a NOTICE file and the modules that ship it.
+type notice struct {
+	text    string
+	modules []string
+}
+
+// WriteNotice writes a single NOTICE file reproducing the NOTICE files of
+// every dependency, as section 4(d) of the Apache License 2.0 requires.
+// Identical notices are written once, attributed to every module that ships
+// them.
+func WriteNotice(w io.Writer, result *licenseplease.Result) error {
+	notices, err := collectNotices(result)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "This product includes software developed by third parties.")
+	fmt.Fprintln(w, "Their NOTICE files are reproduced below.")
+	for _, n := range notices {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, noticeSeparator)
+		for _, mod := range n.modules {
+			fmt.Fprintln(w, mod)
+		}
+		fmt.Fprintln(w, noticeSeparator)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, n.text)
+	}
+	return nil
+}
+
+// collectNotices returns the distinct NOTICE texts among the artifacts of the
+// result's license files, in the order they were first found.
+func collectNotices(result *licenseplease.Result) ([]*notice, error) {
+	var notices []*notice
+	byText := make(map[string]*notice)
+	for _, lf := range result.LicenseFiles {
+		artifacts, err := licenseArtifacts(lf)
+		if err != nil {
+			return nil, err
+		}
+		for _, artifact := range artifacts {
+			if !isNoticeFile(artifact) {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(lf.Module.Dir, artifact))
+			if err != nil {
+				return nil, fmt.Errorf("reading notice: %w", err)
+			}
+			text := strings.TrimSpace(strings.ReplaceAll(string(content), "\r\n", "\n"))
+			if text == "" {
+				continue
+			}
+
+			attribution := strings.TrimSpace(lf.Module.Path + " " + moduleVersion(lf.Module))
+			if filepath.Dir(artifact) != "." {
+				attribution += " (" + filepath.ToSlash(artifact) + ")"
+			}
+			n, ok := byText[text]
+			if !ok {
+				n = &notice{text: text}
+				byText[text] = n
+				notices = append(notices, n)
+			}
+			if !slices.Contains(n.modules, attribution) {
+				n.modules = append(n.modules, attribution)
+			}
+		}
+	}
+	return notices, nil
+}
+
+// isNoticeFile reports whether path names a NOTICE file, such as NOTICE or
+// NOTICE.txt.
+func isNoticeFile(path string) bool {
+	base := filepath.Base(path)
+	return strings.EqualFold(strings.TrimSuffix(base, filepath.Ext(base)), "NOTICE")
+}
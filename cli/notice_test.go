@@ -0,0 +1,57 @@
+package cli_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/williammartin/licenseplease"
+	"github.com/williammartin/licenseplease/cli"
+)
+
+func TestWriteNotice(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"a/LICENSE":    "Apache License",
+		"a/NOTICE":     "Copyright 2020 Example Org\r\n",
+		"b/LICENSE":    "Apache License",
+		"b/NOTICE.txt": "Copyright 2020 Example Org\n\n",
+		"c/LICENSE":    "Apache License",
+		"c/NOTICE":     "Copyright 2023 Other Org\n",
+		"d/LICENSE":    "MIT License",
+	}
+	writeFiles(t, tmpDir, files)
+
+	apache := licenseplease.License{Name: "Apache-2.0", Type: licenseplease.Apache2{}}
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			moduleLicenseFile(tmpDir, "a", "v1.0.0", apache),
+			moduleLicenseFile(tmpDir, "b", "v2.0.0", apache),
+			moduleLicenseFile(tmpDir, "c", "v0.1.0", apache),
+			moduleLicenseFile(tmpDir, "d", "v1.0.0", licenseplease.License{Name: "MIT", Type: licenseplease.MIT{}}),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteNotice(&buf, result); err != nil {
+		t.Fatalf("WriteNotice() error = %v", err)
+	}
+	output := buf.String()
+
+	// Identical notices are written once, attributed to every module
+	if n := strings.Count(output, "Copyright 2020 Example Org"); n != 1 {
+		t.Errorf("shared notice written %d times, want once:\n%s", n, output)
+	}
+	expected := []string{
+		"github.com/test/a v1.0.0\ngithub.com/test/b v2.0.0\n" + strings.Repeat("=", 80) + "\n\nCopyright 2020 Example Org\n",
+		"github.com/test/c v0.1.0\n" + strings.Repeat("=", 80) + "\n\nCopyright 2023 Other Org\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			t.Errorf("output missing %q:\n%s", e, output)
+		}
+	}
+	if strings.Contains(output, "github.com/test/d") {
+		t.Errorf("module without a NOTICE file is attributed:\n%s", output)
+	}
+}
@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/williammartin/licenseplease"
+)
+
+const (
+	spdxVersion     = "SPDX-2.3"
+	spdxDataLicense = "CC0-1.0"
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	spdxNoAssertion = "NOASSERTION"
+	spdxCreator     = "Tool: license-please"
+)
+
+type spdxDocument struct {
+	SPDXVersion             string                 `json:"spdxVersion"`
+	DataLicense             string                 `json:"dataLicense"`
+	SPDXID                  string                 `json:"SPDXID"`
+	Name                    string                 `json:"name"`
+	DocumentNamespace       string                 `json:"documentNamespace"`
+	CreationInfo            spdxCreationInfo       `json:"creationInfo"`
+	Packages                []spdxPackage          `json:"packages"`
+	ExtractedLicensingInfos []spdxExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+	Relationships           []spdxRelationship     `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Creators []string `json:"creators"`
+	Created  string   `json:"created"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxExtractedLicense struct {
+	LicenseID     string `json:"licenseId"`
+	ExtractedText string `json:"extractedText"`
+	Name          string `json:"name"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// WriteSPDXJSON writes the license report as an SPDX 2.3 JSON document. The
+// name identifies the project the document describes.
+func WriteSPDXJSON(w io.Writer, result *licenseplease.Result, name string) error {
+	doc, err := newSPDXDocument(result, name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// WriteSPDXTagValue writes the license report as an SPDX 2.3 tag-value
+// document. The name identifies the project the document describes.
+func WriteSPDXTagValue(w io.Writer, result *licenseplease.Result, name string) error {
+	doc, err := newSPDXDocument(result, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "SPDXVersion: %s\n", doc.SPDXVersion)
+	fmt.Fprintf(w, "DataLicense: %s\n", doc.DataLicense)
+	fmt.Fprintf(w, "SPDXID: %s\n", doc.SPDXID)
+	fmt.Fprintf(w, "DocumentName: %s\n", doc.Name)
+	fmt.Fprintf(w, "DocumentNamespace: %s\n", doc.DocumentNamespace)
+	for _, creator := range doc.CreationInfo.Creators {
+		fmt.Fprintf(w, "Creator: %s\n", creator)
+	}
+	fmt.Fprintf(w, "Created: %s\n", doc.CreationInfo.Created)
+
+	for _, r := range doc.Relationships {
+		fmt.Fprintf(w, "Relationship: %s %s %s\n", r.SPDXElementID, r.RelationshipType, r.RelatedSPDXElement)
+	}
+
+	for _, pkg := range doc.Packages {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "PackageName: %s\n", pkg.Name)
+		fmt.Fprintf(w, "SPDXID: %s\n", pkg.SPDXID)
+		if pkg.VersionInfo != "" {
+			fmt.Fprintf(w, "PackageVersion: %s\n", pkg.VersionInfo)
+		}
+		fmt.Fprintf(w, "PackageDownloadLocation: %s\n", pkg.DownloadLocation)
+		fmt.Fprintf(w, "FilesAnalyzed: %t\n", pkg.FilesAnalyzed)
+		fmt.Fprintf(w, "PackageLicenseConcluded: %s\n", pkg.LicenseConcluded)
+		fmt.Fprintf(w, "PackageLicenseDeclared: %s\n", pkg.LicenseDeclared)
+		fmt.Fprintf(w, "PackageCopyrightText: %s\n", pkg.CopyrightText)
+		for _, ref := range pkg.ExternalRefs {
+			fmt.Fprintf(w, "ExternalRef: %s %s %s\n", ref.ReferenceCategory, ref.ReferenceType, ref.ReferenceLocator)
+		}
+	}
+
+	for _, info := range doc.ExtractedLicensingInfos {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "LicenseID: %s\n", info.LicenseID)
+		fmt.Fprintf(w, "ExtractedText: <text>%s</text>\n", info.ExtractedText)
+		fmt.Fprintf(w, "LicenseName: %s\n", info.Name)
+	}
+
+	return nil
+}
+
+// newSPDXDocument builds an SPDX document with one package per module.
+func newSPDXDocument(result *licenseplease.Result, name string) (*spdxDocument, error) {
+	namespace, err := spdxNamespace(name)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &spdxDocument{
+		SPDXVersion:       spdxVersion,
+		DataLicense:       spdxDataLicense,
+		SPDXID:            spdxDocumentID,
+		Name:              name,
+		DocumentNamespace: namespace,
+		CreationInfo: spdxCreationInfo{
+			Creators: []string{spdxCreator},
+			Created:  time.Now().UTC().Format(time.RFC3339),
+		},
+		Packages:      []spdxPackage{},
+		Relationships: []spdxRelationship{},
+	}
+
+	for _, group := range groupByModule(result.LicenseFiles) {
+		mod := group[0].Module
+		if isUnlicensed(result, mod) {
+			// Added with the other unlicensed modules below
+			continue
+		}
+		var ids []string
+		for _, lf := range group {
+			fileIDs, extracted, err := spdxLicenseIDs(lf)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range fileIDs {
+				if !slices.Contains(ids, id) {
+					ids = append(ids, id)
+				}
+			}
+			for _, info := range extracted {
+				if !slices.ContainsFunc(doc.ExtractedLicensingInfos, func(e spdxExtractedLicense) bool { return e.LicenseID == info.LicenseID }) {
+					doc.ExtractedLicensingInfos = append(doc.ExtractedLicensingInfos, info)
+				}
+			}
+		}
+
+		expression := spdxNoAssertion
+		if len(ids) > 0 {
+			expression = strings.Join(ids, " AND ")
+		}
+
+		// Where the module offers a choice of licenses, we conclude the one
+		// elected by the policy
+		if i := slices.IndexFunc(result.Modules, func(ml licenseplease.ModuleLicense) bool { return ml.Module.Path == mod.Path && ml.Elected != nil }); i >= 0 {
+			doc.addPackage(mod, result.Modules[i].Elected.String(), result.Modules[i].Expression.String())
+			continue
+		}
+		doc.addPackage(mod, expression, expression)
+	}
+
+	// Modules without a license file declare no license, and none can be concluded
+	for _, mod := range result.Unlicensed {
+		doc.addPackage(mod, spdxNoAssertion, licenseplease.NoLicense)
+	}
+
+	return doc, nil
+}
+
+// addPackage adds a package describing mod to the document.
+func (doc *spdxDocument) addPackage(mod licenseplease.Module, concluded, declared string) {
+	pkg := spdxPackage{
+		Name:             mod.Path,
+		SPDXID:           "SPDXRef-Package-" + spdxIDString(mod.Path+"-"+mod.Version),
+		VersionInfo:      mod.Version,
+		DownloadLocation: spdxNoAssertion,
+		FilesAnalyzed:    false,
+		LicenseConcluded: concluded,
+		LicenseDeclared:  declared,
+		CopyrightText:    spdxNoAssertion,
+		ExternalRefs: []spdxExternalRef{
+			{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: mod.PURL()},
+		},
+	}
+	doc.Packages = append(doc.Packages, pkg)
+	doc.Relationships = append(doc.Relationships, spdxRelationship{
+		SPDXElementID:      spdxDocumentID,
+		RelationshipType:   "DESCRIBES",
+		RelatedSPDXElement: pkg.SPDXID,
+	})
+}
+
+// spdxLicenseIDs returns the SPDX license identifiers for a license file.
+// Files that could not be classified, and licenses that aren't on the SPDX
+// License List, are given a LicenseRef and their text is returned as extracted
+// licensing info.
+func spdxLicenseIDs(lf licenseplease.LicenseFile) ([]string, []spdxExtractedLicense, error) {
+	if lf.IsNotice() && len(lf.Licenses) == 0 {
+		return nil, nil, nil
+	}
+
+	var ids []string
+	var extracted []spdxExtractedLicense
+	extract := func(id, name string) error {
+		content, err := os.ReadFile(lf.Path)
+		if err != nil {
+			return fmt.Errorf("reading license file %s: %w", lf.Path, err)
+		}
+		ids = append(ids, id)
+		extracted = append(extracted, spdxExtractedLicense{LicenseID: id, ExtractedText: string(content), Name: name})
+		return nil
+	}
+
+	ref := "LicenseRef-" + spdxIDString(lf.Module.Path+"-"+lf.RelPath)
+	if len(lf.Licenses) == 0 {
+		if err := extract(ref, "Unknown license in "+lf.Module.Path+"/"+lf.RelPath); err != nil {
+			return nil, nil, err
+		}
+		return ids, extracted, nil
+	}
+
+	for _, l := range lf.Licenses {
+		if l.Declared != "" {
+			// Declared by a tag, so already an SPDX identifier
+			ids = append(ids, l.Name)
+			continue
+		}
+		if !licenseplease.IsSPDXLicenseID(l.Name) {
+			if err := extract(ref+"-"+spdxIDString(l.Name), l.Name); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		ids = append(ids, l.Name)
+	}
+	return ids, extracted, nil
+}
+
+// groupByModule groups license files by module, preserving their order.
+func groupByModule(licenseFiles []licenseplease.LicenseFile) [][]licenseplease.LicenseFile {
+	var groups [][]licenseplease.LicenseFile
+	index := make(map[string]int)
+	for _, lf := range licenseFiles {
+		key := lf.Module.Path + "@" + lf.Module.Version
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], lf)
+	}
+	return groups
+}
+
+// moduleLicense returns the rolled up license of mod, or nil if it has none.
+func moduleLicense(result *licenseplease.Result, mod licenseplease.Module) *licenseplease.ModuleLicense {
+	for i, ml := range result.Modules {
+		if ml.Module.Path == mod.Path && ml.Module.Version == mod.Version {
+			return &result.Modules[i]
+		}
+	}
+	return nil
+}
+
+// isUnlicensed reports whether mod is one of the result's unlicensed modules,
+// whose license files are only NOTICE or COPYRIGHT files.
+func isUnlicensed(result *licenseplease.Result, mod licenseplease.Module) bool {
+	return slices.ContainsFunc(result.Unlicensed, func(m licenseplease.Module) bool {
+		return m.Path == mod.Path && m.Version == mod.Version
+	})
+}
+
+var spdxIDInvalidChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// spdxIDString replaces characters that are not allowed in SPDX identifiers.
+func spdxIDString(s string) string {
+	return spdxIDInvalidChars.ReplaceAllString(s, "-")
+}
+
+// spdxNamespace returns a unique document namespace for the named project.
+func spdxNamespace(name string) (string, error) {
+	uuid, err := newUUID()
+	if err != nil {
+		return "", fmt.Errorf("generating document namespace: %w", err)
+	}
+	return fmt.Sprintf("https://spdx.org/spdxdocs/%s-%s", spdxIDString(name), uuid), nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return "", err
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16]), nil
+}
@@ -0,0 +1,265 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/williammartin/licenseplease"
+	"github.com/williammartin/licenseplease/cli"
+)
+
+func spdxResult(t *testing.T) *licenseplease.Result {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"mit/LICENSE":     "MIT License",
+		"mit/NOTICE":      "Notice content",
+		"custom/LICENSE":  "Custom License\nDo what you want.",
+		"gpl/LICENSE":     "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007",
+		"unknown/LICENSE": "Some text nobody recognises",
+	}
+	writeFiles(t, tmpDir, files)
+
+	mit := licenseplease.Module{Path: "github.com/test/mit", Version: "v1.0.0", Dir: filepath.Join(tmpDir, "mit")}
+	custom := licenseplease.Module{Path: "github.com/test/custom", Version: "v0.1.0", Dir: filepath.Join(tmpDir, "custom")}
+	gpl := licenseplease.Module{Path: "github.com/test/gpl", Version: "v3.0.0", Dir: filepath.Join(tmpDir, "gpl")}
+	unknown := licenseplease.Module{Path: "github.com/test/unknown", Version: "v2.0.0+incompatible", Dir: filepath.Join(tmpDir, "unknown")}
+
+	return &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:     filepath.Join(tmpDir, "custom/LICENSE"),
+				RelPath:  "LICENSE",
+				Module:   custom,
+				Licenses: []licenseplease.License{{Name: "Custom", Type: licenseplease.LicenseTypeFromSPDX("Custom")}},
+			},
+			{
+				Path:     filepath.Join(tmpDir, "gpl/LICENSE"),
+				RelPath:  "LICENSE",
+				Module:   gpl,
+				Licenses: []licenseplease.License{{Name: "GPL-3.0", Type: licenseplease.LicenseTypeFromSPDX("GPL-3.0")}},
+			},
+			{
+				Path:     filepath.Join(tmpDir, "mit/LICENSE"),
+				RelPath:  "LICENSE",
+				Module:   mit,
+				Licenses: []licenseplease.License{{Name: "MIT", Type: licenseplease.MIT{}}},
+			},
+			{
+				Path:    filepath.Join(tmpDir, "mit/NOTICE"),
+				RelPath: "NOTICE",
+				Module:  mit,
+			},
+			{
+				Path:    filepath.Join(tmpDir, "unknown/LICENSE"),
+				RelPath: "LICENSE",
+				Module:  unknown,
+			},
+		},
+	}
+}
+
+func TestWriteSPDXJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteSPDXJSON(&buf, spdxResult(t), "my-project"); err != nil {
+		t.Fatalf("WriteSPDXJSON() error = %v", err)
+	}
+
+	var doc struct {
+		SPDXVersion       string `json:"spdxVersion"`
+		DataLicense       string `json:"dataLicense"`
+		SPDXID            string `json:"SPDXID"`
+		Name              string `json:"name"`
+		DocumentNamespace string `json:"documentNamespace"`
+		Packages          []struct {
+			Name             string `json:"name"`
+			SPDXID           string `json:"SPDXID"`
+			VersionInfo      string `json:"versionInfo"`
+			LicenseConcluded string `json:"licenseConcluded"`
+			LicenseDeclared  string `json:"licenseDeclared"`
+			ExternalRefs     []struct {
+				ReferenceType    string `json:"referenceType"`
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+		ExtractedLicensingInfos []struct {
+			LicenseID     string `json:"licenseId"`
+			ExtractedText string `json:"extractedText"`
+			Name          string `json:"name"`
+		} `json:"hasExtractedLicensingInfos"`
+		Relationships []struct {
+			RelationshipType string `json:"relationshipType"`
+		} `json:"relationships"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	if doc.SPDXVersion != "SPDX-2.3" || doc.DataLicense != "CC0-1.0" || doc.SPDXID != "SPDXRef-DOCUMENT" {
+		t.Errorf("unexpected document header: %+v", doc)
+	}
+	if doc.Name != "my-project" || !strings.HasPrefix(doc.DocumentNamespace, "https://spdx.org/spdxdocs/my-project-") {
+		t.Errorf("unexpected document name/namespace: %q %q", doc.Name, doc.DocumentNamespace)
+	}
+
+	if len(doc.Packages) != 4 {
+		t.Fatalf("expected one package per module (4), got %d", len(doc.Packages))
+	}
+	if len(doc.Relationships) != 4 {
+		t.Errorf("expected 4 relationships, got %d", len(doc.Relationships))
+	}
+
+	byName := make(map[string]int)
+	for i, pkg := range doc.Packages {
+		byName[pkg.Name] = i
+	}
+
+	mit := doc.Packages[byName["github.com/test/mit"]]
+	if mit.LicenseConcluded != "MIT" || mit.LicenseDeclared != "MIT" {
+		t.Errorf("mit package licenses = %q/%q, want MIT", mit.LicenseConcluded, mit.LicenseDeclared)
+	}
+	if len(mit.ExternalRefs) != 1 || mit.ExternalRefs[0].ReferenceType != "purl" || mit.ExternalRefs[0].ReferenceLocator != "pkg:golang/github.com/test/mit@v1.0.0" {
+		t.Errorf("unexpected mit external refs: %+v", mit.ExternalRefs)
+	}
+
+	custom := doc.Packages[byName["github.com/test/custom"]]
+	if custom.LicenseConcluded != "LicenseRef-github.com-test-custom-LICENSE-Custom" {
+		t.Errorf("custom package license = %q, want a LicenseRef", custom.LicenseConcluded)
+	}
+
+	// Licenses on the SPDX License List are written as-is, even when not allowed
+	gpl := doc.Packages[byName["github.com/test/gpl"]]
+	if gpl.LicenseConcluded != "GPL-3.0" || gpl.LicenseDeclared != "GPL-3.0" {
+		t.Errorf("gpl package licenses = %q/%q, want GPL-3.0", gpl.LicenseConcluded, gpl.LicenseDeclared)
+	}
+
+	unknown := doc.Packages[byName["github.com/test/unknown"]]
+	if !strings.HasPrefix(unknown.LicenseConcluded, "LicenseRef-") {
+		t.Errorf("unclassified package license = %q, want a LicenseRef", unknown.LicenseConcluded)
+	}
+
+	if len(doc.ExtractedLicensingInfos) != 2 {
+		t.Fatalf("expected 2 extracted licenses, got %d", len(doc.ExtractedLicensingInfos))
+	}
+	for _, info := range doc.ExtractedLicensingInfos {
+		if info.LicenseID == custom.LicenseConcluded && info.ExtractedText != "Custom License\nDo what you want." {
+			t.Errorf("extracted text = %q, want license content", info.ExtractedText)
+		}
+	}
+}
+
+func TestWriteSPDXTagValue(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cli.WriteSPDXTagValue(&buf, spdxResult(t), "my-project"); err != nil {
+		t.Fatalf("WriteSPDXTagValue() error = %v", err)
+	}
+
+	output := buf.String()
+	expected := []string{
+		"SPDXVersion: SPDX-2.3\n",
+		"DataLicense: CC0-1.0\n",
+		"SPDXID: SPDXRef-DOCUMENT\n",
+		"DocumentName: my-project\n",
+		"Creator: Tool: license-please\n",
+		"PackageName: github.com/test/mit\n",
+		"SPDXID: SPDXRef-Package-github.com-test-mit-v1.0.0\n",
+		"PackageVersion: v1.0.0\n",
+		"PackageLicenseConcluded: MIT\n",
+		"PackageLicenseConcluded: GPL-3.0\n",
+		"ExternalRef: PACKAGE-MANAGER purl pkg:golang/github.com/test/mit@v1.0.0\n",
+		"ExternalRef: PACKAGE-MANAGER purl pkg:golang/github.com/test/unknown@v2.0.0%2Bincompatible\n",
+		"Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-Package-github.com-test-mit-v1.0.0\n",
+		"LicenseID: LicenseRef-github.com-test-custom-LICENSE-Custom\n",
+		"ExtractedText: <text>Custom License\nDo what you want.</text>\n",
+		"LicenseName: Custom\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			t.Errorf("output missing %q", e)
+		}
+	}
+	if strings.Contains(output, "Notice content") {
+		t.Error("NOTICE files should not be extracted as licenses")
+	}
+}
+
+func TestWriteSPDXJSON_ElectedLicense(t *testing.T) {
+	tmpDir := t.TempDir()
+	licensePath := filepath.Join(tmpDir, "LICENSE")
+	os.WriteFile(licensePath, []byte("SPDX-License-Identifier: MIT OR Apache-2.0"), 0644)
+
+	mod := licenseplease.Module{Path: "github.com/test/dual", Version: "v1.0.0", Dir: tmpDir}
+	expr, err := licenseplease.ParseExpression("MIT OR Apache-2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{
+				Path:    licensePath,
+				RelPath: "LICENSE",
+				Module:  mod,
+				Licenses: []licenseplease.License{
+					{Name: "MIT", Type: licenseplease.MIT{}, Declared: "MIT OR Apache-2.0"},
+					{Name: "Apache-2.0", Type: licenseplease.Apache2{}, Declared: "MIT OR Apache-2.0"},
+				},
+				Elected: expr.Operands[0],
+			},
+		},
+		Modules: []licenseplease.ModuleLicense{{Module: mod, Expression: expr, Elected: expr.Operands[0]}},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteSPDXJSON(&buf, result, "my-project"); err != nil {
+		t.Fatalf("WriteSPDXJSON() error = %v", err)
+	}
+
+	var doc struct {
+		Packages []struct {
+			LicenseConcluded string `json:"licenseConcluded"`
+			LicenseDeclared  string `json:"licenseDeclared"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(doc.Packages) != 1 || doc.Packages[0].LicenseConcluded != "MIT" || doc.Packages[0].LicenseDeclared != "MIT OR Apache-2.0" {
+		t.Errorf("packages = %+v, want MIT concluded from MIT OR Apache-2.0", doc.Packages)
+	}
+}
+
+func TestWriteSPDXJSON_NoticeOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{"NOTICE": "Copyright 2020 Example Org. All rights reserved."})
+
+	mod := licenseplease.Module{Path: "github.com/test/notice", Version: "v1.0.0", Dir: tmpDir}
+	result := &licenseplease.Result{
+		LicenseFiles: []licenseplease.LicenseFile{
+			{Path: filepath.Join(tmpDir, "NOTICE"), RelPath: "NOTICE", Module: mod},
+		},
+		Unlicensed: []licenseplease.Module{mod},
+	}
+
+	var buf bytes.Buffer
+	if err := cli.WriteSPDXJSON(&buf, result, "my-project"); err != nil {
+		t.Fatalf("WriteSPDXJSON() error = %v", err)
+	}
+
+	var doc struct {
+		Packages []struct {
+			Name            string `json:"name"`
+			LicenseDeclared string `json:"licenseDeclared"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(doc.Packages) != 1 || doc.Packages[0].LicenseDeclared != licenseplease.NoLicense {
+		t.Errorf("packages = %+v, want the module once, declaring %s", doc.Packages, licenseplease.NoLicense)
+	}
+}
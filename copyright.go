@@ -0,0 +1,111 @@
+package licenseplease
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Copyright is a copyright statement found in a license file, such as
+// "Copyright (c) 2012-2016 Dave Collins".
+type Copyright struct {
+	// Years are the years of the statement as written, e.g. "2012-2016" or
+	// "2019, 2021". Empty if the statement has none.
+	Years  string
+	Holder string
+}
+
+func (c Copyright) String() string {
+	if c.Years == "" {
+		return "Copyright (c) " + c.Holder
+	}
+	return "Copyright (c) " + c.Years + " " + c.Holder
+}
+
+var (
+	// copyrightPrefixPattern matches the start of a copyright statement:
+	// "Copyright", "(c)" and "©" in any combination.
+	copyrightPrefixPattern = regexp.MustCompile(`(?i)^\s*(?:(?:copyright\b:?|\(c\)|©)\s*)+`)
+	copyrightYearsPattern  = regexp.MustCompile(`(?i)^\d{4}(?:\s*[-–]\s*(?:\d{4}|present))?(?:\s*,\s*\d{4}(?:\s*[-–]\s*(?:\d{4}|present))?)*`)
+	allRightsPattern       = regexp.MustCompile(`(?i)[\s.,;]*all rights reserved\.?\s*$`)
+)
+
+// parseCopyright parses a line holding a copyright statement. Lines that only
+// mention copyright, such as "The above copyright notice ..." or the
+// "(c)" clause of the Apache License, are not statements: they need years,
+// or the word Copyright together with a copyright sign.
+func parseCopyright(line string) (Copyright, bool) {
+	prefix := copyrightPrefixPattern.FindString(line)
+	if prefix == "" {
+		return Copyright{}, false
+	}
+	lower := strings.ToLower(prefix)
+	signed := strings.Contains(lower, "copyright") && (strings.Contains(lower, "(c)") || strings.Contains(lower, "©"))
+
+	rest := line[len(prefix):]
+	years := copyrightYearsPattern.FindString(rest)
+	if years == "" && !signed {
+		return Copyright{}, false
+	}
+	rest = strings.TrimLeft(rest[len(years):], " ,")
+
+	holder := allRightsPattern.ReplaceAllString(rest, "")
+	holder = strings.TrimPrefix(holder, "by ")
+	holder = strings.TrimRight(strings.TrimSpace(holder), ".,;")
+	if holder == "" {
+		return Copyright{}, false
+	}
+	return Copyright{Years: years, Holder: holder}, true
+}
+
+// IsCopyrightStatement reports whether line holds a copyright statement, as
+// extracted by ReadCopyrights.
+func IsCopyrightStatement(line string) bool {
+	_, ok := parseCopyright(line)
+	return ok
+}
+
+// ReadCopyrights returns the distinct copyright statements in the file at
+// path, in order.
+func ReadCopyrights(path string) ([]Copyright, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading license file: %w", err)
+	}
+	var copyrights []Copyright
+	for _, line := range strings.Split(string(content), "\n") {
+		c, ok := parseCopyright(line)
+		if !ok {
+			continue
+		}
+		if !slices.Contains(copyrights, c) {
+			copyrights = append(copyrights, c)
+		}
+	}
+	return copyrights, nil
+}
+
+// CopyrightHolders returns the distinct copyright holders of the license
+// files, sorted alphabetically. Holders differing only in case are listed
+// once, as first written.
+func CopyrightHolders(licenseFiles []LicenseFile) []string {
+	seen := make(map[string]bool)
+	var holders []string
+	for _, lf := range licenseFiles {
+		for _, c := range lf.Copyrights {
+			key := strings.ToLower(c.Holder)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			holders = append(holders, c.Holder)
+		}
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		return strings.ToLower(holders[i]) < strings.ToLower(holders[j])
+	})
+	return holders
+}
@@ -0,0 +1,77 @@
+package licenseplease
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCopyright(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line string
+		want Copyright
+		ok   bool
+	}{
+		{"Copyright (c) 2012 Alex Ogier. All rights reserved.", Copyright{"2012", "Alex Ogier"}, true},
+		{"Copyright 2011-2016 Canonical Ltd.", Copyright{"2011-2016", "Canonical Ltd"}, true},
+		{"Copyright (C) 2018 Alec Thomas", Copyright{"2018", "Alec Thomas"}, true},
+		{"Copyright (c) 2013, Patrick Mezard", Copyright{"2013", "Patrick Mezard"}, true},
+		{"   Copyright 2019, 2021 by The Authors", Copyright{"2019, 2021", "The Authors"}, true},
+		{"Copyright © 2020-present Jane Doe", Copyright{"2020-present", "Jane Doe"}, true},
+		{"Copyright (c) The Go Authors", Copyright{"", "The Go Authors"}, true},
+		{"copyright notice, this list of conditions and the following disclaimer.", Copyright{}, false},
+		{"(c) You must retain, in the Source form of any Derivative Works", Copyright{}, false},
+		{"Copyright [yyyy] [name of copyright owner]", Copyright{}, false},
+		{"copyright staring in 2011", Copyright{}, false},
+		{"Copyright (c) 2012", Copyright{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseCopyright(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parseCopyright(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestReadCopyrights(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "LICENSE")
+	content := "MIT License\n\nCopyright (c) 2020 Jane Doe\nCopyright (c) 2020 Jane Doe\nCopyright (c) 2021 John Doe\n\n" +
+		"The above copyright notice and this permission notice shall be included in all copies.\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadCopyrights(path)
+	if err != nil {
+		t.Fatalf("ReadCopyrights() error = %v", err)
+	}
+	want := []Copyright{{"2020", "Jane Doe"}, {"2021", "John Doe"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadCopyrights() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCopyrightHolders(t *testing.T) {
+	t.Parallel()
+
+	licenseFiles := []LicenseFile{
+		{Copyrights: []Copyright{{"2020", "the Go Authors"}, {"2019", "Zed"}}},
+		{Copyrights: []Copyright{{"2021", "The Go Authors"}, {"2018", "alice"}}},
+		{},
+	}
+
+	got := CopyrightHolders(licenseFiles)
+	want := []string{"alice", "the Go Authors", "Zed"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CopyrightHolders() = %v, want %v", got, want)
+	}
+}
@@ -2,7 +2,9 @@ package licenseplease_test
 
 import (
 	"context"
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"slices"
@@ -366,3 +368,609 @@ func TestE2E_LicenseURL(t *testing.T) {
 		}
 	}
 }
+
+// TestE2E_Run_PolicyDeniesLicense verifies that Run() fails when the policy denies a license in use.
+func TestE2E_Run_PolicyDeniesLicense(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	policy := licenseplease.DefaultPolicy()
+	policy.Allow = slices.DeleteFunc(policy.Allow, func(spdx string) bool { return spdx == "Apache-2.0" })
+	policy.Deny = append(policy.Deny, "Apache-2.0")
+
+	result, err := licenseplease.Run(context.Background(), e2eDir, licenseplease.WithPolicy(policy))
+	if err == nil {
+		t.Fatal("Run() should fail when the policy denies Apache-2.0")
+	}
+	if !strings.Contains(err.Error(), "github.com/spf13/cobra") {
+		t.Errorf("error should mention cobra, got: %v", err)
+	}
+
+	var policyErr *licenseplease.PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected a *PolicyError, got %T", err)
+	}
+	found := false
+	for _, v := range policyErr.Violations {
+		if v.Module == "github.com/spf13/cobra" && v.License == "Apache-2.0" && v.Reason == "license is denied by policy" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a violation for cobra's Apache-2.0 license, got %v", policyErr.Violations)
+	}
+	if result == nil || len(result.LicenseFiles) == 0 {
+		t.Error("Run() should return the full result alongside the policy error")
+	}
+}
+
+// TestE2E_Run_PolicyReview verifies that licenses marked for review are reported without failing.
+func TestE2E_Run_PolicyReview(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	policy := licenseplease.DefaultPolicy()
+	policy.Allow = slices.DeleteFunc(policy.Allow, func(spdx string) bool { return spdx == "ISC" })
+	policy.Review = append(policy.Review, "ISC")
+
+	result, err := licenseplease.Run(context.Background(), e2eDir, licenseplease.WithPolicy(policy))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	found := false
+	for _, v := range result.NeedsReview {
+		if v.Module == "github.com/davecgh/go-spew" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected go-spew to need review, got %v", result.NeedsReview)
+	}
+}
+
+// TestE2E_Run_PolicyException verifies that exceptions waive denied licenses until they expire.
+func TestE2E_Run_PolicyException(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	newPolicy := func(expires string) *licenseplease.Policy {
+		policy := licenseplease.DefaultPolicy()
+		policy.Allow = slices.DeleteFunc(policy.Allow, func(spdx string) bool { return spdx == "BSD-3-Clause" })
+		policy.Exceptions = []licenseplease.Exception{
+			{Module: "github.com/spf13/pflag", Reason: "Approved by legal", Expires: expires},
+			{Module: "github.com/pmezard/go-difflib", Reason: "Approved by legal", Expires: expires},
+		}
+		return policy
+	}
+
+	result, err := licenseplease.Run(context.Background(), e2eDir, licenseplease.WithPolicy(newPolicy("")))
+	if err != nil {
+		t.Fatalf("Run() with exceptions error = %v", err)
+	}
+	waived := false
+	for _, lf := range result.LicenseFiles {
+		for _, l := range lf.Licenses {
+			if lf.Module.Path == "github.com/spf13/pflag" && l.Waiver != nil {
+				waived = true
+			}
+		}
+	}
+	if !waived {
+		t.Error("expected pflag license to be marked as waived")
+	}
+
+	_, err = licenseplease.Run(context.Background(), e2eDir, licenseplease.WithPolicy(newPolicy("2000-01-01")))
+	if err == nil {
+		t.Fatal("Run() should fail when exceptions have expired")
+	}
+	if !strings.Contains(err.Error(), "exception expired") {
+		t.Errorf("error should mention the expired exception, got: %v", err)
+	}
+}
+
+// TestE2E_Check verifies that Check() records violations instead of failing.
+func TestE2E_Check(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	policy := &licenseplease.Policy{Allow: []string{"MIT", "ISC", "BSD-3-Clause"}}
+
+	result, err := licenseplease.Check(context.Background(), e2eDir, licenseplease.WithPolicy(policy))
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if len(result.LicenseFiles) == 0 {
+		t.Error("Check() returned no license files")
+	}
+
+	found := false
+	for _, v := range result.Violations {
+		if v.Module == "github.com/spf13/cobra" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected cobra's Apache-2.0 license to be a violation, got %v", result.Violations)
+	}
+}
+
+// TestE2E_GoListResolver verifies that only modules linked into the build are resolved.
+func TestE2E_GoListResolver(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	resolver := &licenseplease.GoListResolver{}
+	modules, err := resolver.Resolve(context.Background(), e2eDir)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	found := make(map[string]licenseplease.Module)
+	for _, m := range modules {
+		if _, dup := found[m.Path]; dup {
+			t.Errorf("module %s resolved more than once", m.Path)
+		}
+		found[m.Path] = m
+	}
+
+	for _, expected := range []string{"github.com/spf13/cobra", "github.com/spf13/pflag", "github.com/stretchr/testify", "gopkg.in/yaml.v3"} {
+		m, ok := found[expected]
+		if !ok {
+			t.Errorf("expected module %s not resolved", expected)
+			continue
+		}
+		if m.Version == "" || m.Dir == "" {
+			t.Errorf("module %s missing version or dir: %+v", expected, m)
+		}
+	}
+
+	// mousetrap is only imported by cobra on windows
+	if runtime.GOOS != "windows" {
+		if _, ok := found["github.com/inconshreveable/mousetrap"]; ok {
+			t.Error("mousetrap should not be linked on non-windows platforms")
+		}
+	}
+
+	if _, ok := found["github.com/williammartin/licenseplease/testdata/e2e"]; ok {
+		t.Error("the main module should not be resolved as a dependency")
+	}
+}
+
+// TestE2E_BuildInfoResolver verifies that modules are resolved from a compiled binary's build info.
+func TestE2E_BuildInfoResolver(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	binary := filepath.Join(t.TempDir(), "e2e")
+	build := exec.Command("go", "build", "-o", binary, ".")
+	build.Dir = e2eDir
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("building e2e binary: %v\n%s", err, output)
+	}
+
+	resolver := &licenseplease.BuildInfoResolver{Binary: binary}
+	modules, err := resolver.Resolve(context.Background(), e2eDir)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	found := make(map[string]licenseplease.Module)
+	for _, m := range modules {
+		found[m.Path] = m
+	}
+
+	expected := map[string]string{
+		"github.com/spf13/cobra":      "v1.8.0",
+		"github.com/spf13/pflag":      "v1.0.5",
+		"github.com/stretchr/testify": "v1.8.4",
+	}
+	for path, version := range expected {
+		m, ok := found[path]
+		if !ok {
+			t.Errorf("expected module %s not resolved", path)
+			continue
+		}
+		if m.Version != version {
+			t.Errorf("module %s version = %s, want %s", path, m.Version, version)
+		}
+		if m.Dir == "" {
+			t.Errorf("module %s was not resolved from the module cache", path)
+		}
+	}
+
+	if _, ok := found["github.com/inconshreveable/mousetrap"]; ok && runtime.GOOS != "windows" {
+		t.Error("mousetrap is not linked into non-windows binaries")
+	}
+}
+
+// TestE2E_GoListResolver_TargetPlatform verifies that the resolved modules follow the target platform.
+func TestE2E_GoListResolver_TargetPlatform(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	tests := []struct {
+		goos          string
+		wantMousetrap bool
+	}{
+		{"windows", true},
+		{"linux", false},
+		{"darwin", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			resolver := &licenseplease.GoListResolver{GOOS: tt.goos, GOARCH: "amd64"}
+			modules, err := resolver.Resolve(context.Background(), e2eDir)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+
+			gotMousetrap := slices.ContainsFunc(modules, func(m licenseplease.Module) bool {
+				return m.Path == "github.com/inconshreveable/mousetrap"
+			})
+			if gotMousetrap != tt.wantMousetrap {
+				t.Errorf("GOOS=%s: mousetrap resolved = %v, want %v", tt.goos, gotMousetrap, tt.wantMousetrap)
+			}
+		})
+	}
+}
+
+// TestE2E_Workspace verifies that dependencies of every module in a go.work workspace are resolved and attributed.
+func TestE2E_Workspace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	workspaceDir := filepath.Join(filepath.Dir(thisFile), "testdata", "workspace")
+
+	resolver := &licenseplease.GoModResolver{}
+	modules, err := resolver.Resolve(context.Background(), workspaceDir)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	found := make(map[string]licenseplease.Module)
+	for _, m := range modules {
+		found[m.Path] = m
+	}
+	requiredBy, err := resolver.RequiredBy(context.Background(), workspaceDir)
+	if err != nil {
+		t.Fatalf("RequiredBy() error = %v", err)
+	}
+
+	expected := map[string][]string{
+		"github.com/spf13/pflag":     {"github.com/williammartin/licenseplease/testdata/workspace/a"},
+		"github.com/davecgh/go-spew": {"github.com/williammartin/licenseplease/testdata/workspace/b"},
+	}
+	for path, want := range expected {
+		if _, ok := found[path]; !ok {
+			t.Errorf("expected module %s not resolved", path)
+			continue
+		}
+		if !slices.Equal(requiredBy[path], want) {
+			t.Errorf("module %s required by %v, want %v", path, requiredBy[path], want)
+		}
+	}
+
+	for path := range found {
+		if strings.HasPrefix(path, "github.com/williammartin/licenseplease/testdata/workspace") {
+			t.Errorf("workspace module %s should not be reported as a dependency", path)
+		}
+	}
+}
+
+// TestE2E_VendorResolver verifies that licenses are found in a vendored copy of the e2e module.
+func TestE2E_VendorResolver(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	// Vendor a copy of the e2e module so the testdata stays untouched
+	projectDir := t.TempDir()
+	for _, name := range []string{"go.mod", "go.sum", "main.go"} {
+		content, err := os.ReadFile(filepath.Join(e2eDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(projectDir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vendor := exec.Command("go", "mod", "vendor")
+	vendor.Dir = projectDir
+	if output, err := vendor.CombinedOutput(); err != nil {
+		t.Fatalf("go mod vendor: %v\n%s", err, output)
+	}
+
+	classifier, err := licenseplease.NewGoogleLicenseClassifier()
+	if err != nil {
+		t.Fatalf("failed to create classifier: %v", err)
+	}
+
+	aggregator := &licenseplease.Aggregator{
+		Resolver:   &licenseplease.VendorResolver{},
+		Finder:     &licenseplease.RecursiveLicenseFinder{},
+		Classifier: classifier,
+	}
+
+	licenseFiles, err := aggregator.Aggregate(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+
+	moduleToLicenses := make(map[string][]string)
+	for _, lf := range licenseFiles {
+		if !strings.HasPrefix(lf.Path, filepath.Join(projectDir, "vendor")) {
+			t.Errorf("license file %s is outside the vendor directory", lf.Path)
+		}
+		for _, lic := range lf.Licenses {
+			moduleToLicenses[lf.Module.Path] = append(moduleToLicenses[lf.Module.Path], lic.Name)
+		}
+	}
+
+	expectedLicenses := map[string]string{
+		"github.com/spf13/cobra":      "Apache-2.0",
+		"github.com/spf13/pflag":      "BSD-3-Clause",
+		"github.com/stretchr/testify": "MIT",
+	}
+	for module, license := range expectedLicenses {
+		if !slices.Contains(moduleToLicenses[module], license) {
+			t.Errorf("module %s: expected license %s, got %v", module, license, moduleToLicenses[module])
+		}
+	}
+}
+
+// TestE2E_GoListResolver_Vendor verifies that the build resolver finds
+// licenses in the vendor directory when the project builds with -mod=vendor.
+func TestE2E_GoListResolver_Vendor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	// Vendor a copy of the e2e module so the testdata stays untouched
+	projectDir := t.TempDir()
+	for _, name := range []string{"go.mod", "go.sum", "main.go"} {
+		content, err := os.ReadFile(filepath.Join(e2eDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(projectDir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vendor := exec.Command("go", "mod", "vendor")
+	vendor.Dir = projectDir
+	if output, err := vendor.CombinedOutput(); err != nil {
+		t.Fatalf("go mod vendor: %v\n%s", err, output)
+	}
+	// go list only leaves module directories empty when building from vendor
+	t.Setenv("GOFLAGS", "-mod=vendor")
+
+	classifier, err := licenseplease.NewGoogleLicenseClassifier()
+	if err != nil {
+		t.Fatalf("failed to create classifier: %v", err)
+	}
+
+	aggregator := &licenseplease.Aggregator{
+		Resolver:   &licenseplease.GoListResolver{},
+		Finder:     &licenseplease.RecursiveLicenseFinder{},
+		Classifier: classifier,
+	}
+
+	aggregation, err := aggregator.Scan(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(aggregation.Unlicensed) > 0 {
+		t.Errorf("expected every vendored module to be licensed, got unlicensed %v", aggregation.Unlicensed)
+	}
+
+	moduleToLicenses := make(map[string][]string)
+	for _, lf := range aggregation.LicenseFiles {
+		if !strings.HasPrefix(lf.Path, filepath.Join(projectDir, "vendor")) {
+			t.Errorf("license file %s is outside the vendor directory", lf.Path)
+		}
+		for _, lic := range lf.Licenses {
+			moduleToLicenses[lf.Module.Path] = append(moduleToLicenses[lf.Module.Path], lic.Name)
+		}
+	}
+
+	expectedLicenses := map[string]string{
+		"github.com/spf13/cobra":      "Apache-2.0",
+		"github.com/spf13/pflag":      "BSD-3-Clause",
+		"github.com/stretchr/testify": "MIT",
+	}
+	for module, license := range expectedLicenses {
+		if !slices.Contains(moduleToLicenses[module], license) {
+			t.Errorf("module %s: expected license %s, got %v", module, license, moduleToLicenses[module])
+		}
+	}
+}
+
+func TestE2E_ReplaceDirectives(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	replaceDir := filepath.Join(filepath.Dir(thisFile), "testdata", "replace")
+
+	result, err := licenseplease.Run(context.Background(), replaceDir)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	found := make(map[string]licenseplease.LicenseFile)
+	for _, lf := range result.LicenseFiles {
+		found[lf.Module.Path] = lf
+	}
+
+	// Local filesystem replacement: the fork's own license is scanned
+	fork, ok := found["github.com/spf13/pflag"]
+	if !ok {
+		t.Fatal("expected license for locally replaced github.com/spf13/pflag")
+	}
+	if fork.Module.Version != "v1.0.5" || fork.Module.Replace == nil || fork.Module.Replace.Path != "./fork" {
+		t.Errorf("unexpected module for local replacement: %+v", fork.Module)
+	}
+	if fork.Path != filepath.Join(replaceDir, "fork", "LICENSE") {
+		t.Errorf("local replacement license path = %s, want the fork's LICENSE", fork.Path)
+	}
+	if len(fork.Licenses) != 1 || fork.Licenses[0].Name != "MIT" {
+		t.Errorf("local replacement licenses = %+v, want MIT", fork.Licenses)
+	}
+	if url := fork.LicenseURL(); url != "" {
+		t.Errorf("local replacement LicenseURL() = %q, want empty", url)
+	}
+
+	// Versioned replacement: the original version is kept alongside the replacement
+	spew, ok := found["github.com/davecgh/go-spew"]
+	if !ok {
+		t.Fatal("expected license for replaced github.com/davecgh/go-spew")
+	}
+	if spew.Module.Version != "v1.1.1" || spew.Module.Replace == nil || spew.Module.Replace.Version != "v1.1.0" {
+		t.Errorf("unexpected module for versioned replacement: %+v", spew.Module)
+	}
+	if want := "https://pkg.go.dev/github.com/davecgh/go-spew@v1.1.0?tab=licenses"; spew.LicenseURL() != want {
+		t.Errorf("versioned replacement LicenseURL() = %q, want %q", spew.LicenseURL(), want)
+	}
+}
+
+func TestE2E_MatchDetails(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	result, err := licenseplease.Check(context.Background(), e2eDir)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	for _, lf := range result.LicenseFiles {
+		if lf.Module.Path != "github.com/davecgh/go-spew" {
+			continue
+		}
+		if len(lf.Licenses) != 1 {
+			t.Fatalf("expected 1 license, got %+v", lf.Licenses)
+		}
+		l := lf.Licenses[0]
+		if l.Confidence < licenseplease.DefaultMinConfidence || l.StartLine != 5 || l.EndLine != 15 {
+			t.Errorf("unexpected match details: confidence %v, lines %d-%d", l.Confidence, l.StartLine, l.EndLine)
+		}
+		if lf.Coverage < licenseplease.DefaultMinCoverage {
+			t.Errorf("coverage = %v, want at least %v for a stock ISC license", lf.Coverage, licenseplease.DefaultMinCoverage)
+		}
+		return
+	}
+	t.Error("expected license for github.com/davecgh/go-spew")
+}
+
+func TestE2E_SourceHeaders(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	_, thisFile, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("failed to get current file path")
+	}
+	e2eDir := filepath.Join(filepath.Dir(thisFile), "testdata", "e2e")
+
+	result, err := licenseplease.Check(context.Background(), e2eDir, licenseplease.WithSourceHeaders())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	// gopkg.in/yaml.v3 is MIT licensed, but the files ported from libyaml
+	// carry Apache-2.0 headers
+	found := false
+	for _, h := range result.SourceHeaders {
+		if h.Module.Path == "gopkg.in/yaml.v3" && h.RelPath == "decode.go" {
+			found = true
+			if len(h.Licenses) != 1 || h.Licenses[0].Name != "Apache-2.0" {
+				t.Errorf("decode.go licenses = %+v, want Apache-2.0", h.Licenses)
+			}
+		}
+		if h.Module.Path == "github.com/spf13/cobra" {
+			t.Errorf("cobra's Apache-2.0 headers match its license and shouldn't be reported: %s", h.RelPath)
+		}
+	}
+	if !found {
+		t.Error("expected gopkg.in/yaml.v3 decode.go to be reported")
+	}
+}
@@ -0,0 +1,395 @@
+package licenseplease
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Expression is a parsed SPDX license expression, such as
+// "MIT OR Apache-2.0" or "GPL-2.0-only WITH Classpath-exception-2.0".
+// A simple expression names a single License, optionally with an Exception.
+// A compound expression combines its Operands with Op, which is AND or OR.
+type Expression struct {
+	License   string
+	Exception string
+
+	Op       string
+	Operands []*Expression
+}
+
+// Operators of SPDX license expressions.
+const (
+	OpAnd  = "AND"
+	OpOr   = "OR"
+	OpWith = "WITH"
+)
+
+// ParseExpression parses an SPDX license expression. Operators may be
+// written in upper or lower case, and AND binds more tightly than OR.
+func ParseExpression(s string) (*Expression, error) {
+	p := &expressionParser{tokens: tokenizeExpression(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("parsing license expression %q: %w", s, err)
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("parsing license expression %q: unexpected %q", s, tok)
+	}
+	return expr, nil
+}
+
+// String formats the expression, adding parentheses only where they are
+// needed.
+func (e *Expression) String() string {
+	if e.Op == "" {
+		if e.Exception != "" {
+			return e.License + " " + OpWith + " " + e.Exception
+		}
+		return e.License
+	}
+	parts := make([]string, len(e.Operands))
+	for i, operand := range e.Operands {
+		parts[i] = operand.String()
+		// OR binds more loosely than AND, so it needs parentheses inside one
+		if e.Op == OpAnd && operand.Op == OpOr {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " "+e.Op+" ")
+}
+
+// Licenses returns the distinct simple expressions within the expression, in
+// order, each formatted as "License" or "License WITH Exception".
+func (e *Expression) Licenses() []string {
+	var licenses []string
+	for _, simple := range e.simple() {
+		licenses = append(licenses, simple.String())
+	}
+	return licenses
+}
+
+// simple returns the distinct simple expressions within the expression.
+func (e *Expression) simple() []*Expression {
+	if e.Op == "" {
+		return []*Expression{e}
+	}
+	var simple []*Expression
+	for _, operand := range e.Operands {
+		for _, s := range operand.simple() {
+			if !slices.ContainsFunc(simple, func(other *Expression) bool { return other.String() == s.String() }) {
+				simple = append(simple, s)
+			}
+		}
+	}
+	return simple
+}
+
+// hasChoice reports whether the expression offers a choice of licenses.
+func (e *Expression) hasChoice() bool {
+	if e.Op == OpOr {
+		return true
+	}
+	return slices.ContainsFunc(e.Operands, (*Expression).hasChoice)
+}
+
+// allOf combines expressions with AND, dropping duplicates. It returns nil if
+// there are no expressions.
+func allOf(exprs []*Expression) *Expression {
+	var operands []*Expression
+	for _, expr := range exprs {
+		flattened := []*Expression{expr}
+		if expr.Op == OpAnd {
+			flattened = expr.Operands
+		}
+		for _, operand := range flattened {
+			if !slices.ContainsFunc(operands, func(other *Expression) bool { return other.String() == operand.String() }) {
+				operands = append(operands, operand)
+			}
+		}
+	}
+	switch len(operands) {
+	case 0:
+		return nil
+	case 1:
+		return operands[0]
+	}
+	return &Expression{Op: OpAnd, Operands: operands}
+}
+
+// licenseExpressionOf returns the simple expression for a license name such
+// as "MIT" or "GPL-2.0-only WITH Classpath-exception-2.0".
+func licenseExpressionOf(name string) *Expression {
+	license, exception, _ := strings.Cut(name, " "+OpWith+" ")
+	return &Expression{License: license, Exception: exception}
+}
+
+// licenseType returns the LicenseType of a simple expression, keeping its
+// exception if it has one.
+func (e *Expression) licenseType() LicenseType {
+	if e.Exception == "" {
+		return LicenseTypeFromSPDX(e.License)
+	}
+	return LicenseWithException{License: LicenseTypeFromSPDX(e.License), Exception: e.Exception}
+}
+
+// evaluateExpression returns the decision for expr given the decision for
+// each license, along with the branch of expr it elected. An AND expression is
+// only as acceptable as its least acceptable operand, and an OR expression is
+// as acceptable as its most acceptable one, which is elected; ties go to the
+// operand listed first.
+func evaluateExpression(expr *Expression, evaluate func(spdx string) Decision) (Decision, *Expression) {
+	switch expr.Op {
+	case OpAnd:
+		decision := Allow
+		var elected []*Expression
+		for _, operand := range expr.Operands {
+			d, e := evaluateExpression(operand, evaluate)
+			decision = max(decision, d)
+			elected = append(elected, e)
+		}
+		return decision, allOf(elected)
+	case OpOr:
+		var elected *Expression
+		decision := Deny
+		for i, operand := range expr.Operands {
+			d, e := evaluateExpression(operand, evaluate)
+			if i == 0 || d < decision {
+				decision, elected = d, e
+			}
+		}
+		return decision, elected
+	}
+	return evaluate(expr.String()), expr
+}
+
+// ModuleLicense is the license of a module as a whole.
+type ModuleLicense struct {
+	Module Module
+	// Expression combines the expressions of the module's license files with
+	// AND.
+	Expression *Expression
+	// Elected is the branch of Expression that the policy elected, when
+	// Expression offers a choice of licenses. It is nil otherwise.
+	Elected *Expression
+}
+
+// ModuleLicenses rolls the licenses of each module's license files up into a
+// single expression per module. License files must be grouped by module, as
+// Check returns them, and the branches elected by Policy.Check are carried
+// over. Modules whose license files declare no license are left out.
+func ModuleLicenses(licenseFiles []LicenseFile) []ModuleLicense {
+	var modules []ModuleLicense
+	for i := 0; i < len(licenseFiles); {
+		mod := licenseFiles[i].Module
+		var exprs, elected []*Expression
+		choice := false
+		for ; i < len(licenseFiles) && licenseFiles[i].Module.Path == mod.Path && licenseFiles[i].Module.Version == mod.Version; i++ {
+			expr := licenseFiles[i].Expression()
+			if expr == nil {
+				continue
+			}
+			exprs = append(exprs, expr)
+			if licenseFiles[i].Elected != nil {
+				choice = true
+				expr = licenseFiles[i].Elected
+			}
+			elected = append(elected, expr)
+		}
+		if len(exprs) == 0 {
+			continue
+		}
+		ml := ModuleLicense{Module: mod, Expression: allOf(exprs)}
+		if choice {
+			ml.Elected = allOf(elected)
+		}
+		modules = append(modules, ml)
+	}
+	return modules
+}
+
+// tokenizeExpression splits an expression into parentheses and words.
+func tokenizeExpression(s string) []string {
+	s = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s)
+	return strings.Fields(s)
+}
+
+type expressionParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *expressionParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+// accept consumes the next token if it is the given operator.
+func (p *expressionParser) accept(op string) bool {
+	tok, ok := p.peek()
+	if ok && strings.ToUpper(tok) == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *expressionParser) parseOr() (*Expression, error) {
+	return p.parseCompound(OpOr, p.parseAnd)
+}
+
+func (p *expressionParser) parseAnd() (*Expression, error) {
+	return p.parseCompound(OpAnd, p.parseSimple)
+}
+
+// parseCompound parses operands joined by op, flattening them into a single
+// expression.
+func (p *expressionParser) parseCompound(op string, operand func() (*Expression, error)) (*Expression, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*Expression{first}
+	for p.accept(op) {
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if next.Op == op {
+			operands = append(operands, next.Operands...)
+		} else {
+			operands = append(operands, next)
+		}
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &Expression{Op: op, Operands: operands}, nil
+}
+
+// parseSimple parses a license, optionally WITH an exception, or a
+// parenthesized expression.
+func (p *expressionParser) parseSimple() (*Expression, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+	if tok == "(" {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, ok := p.peek(); !ok || tok != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return expr, nil
+	}
+	if !isLicenseID(tok) {
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+
+	expr := &Expression{License: tok}
+	if p.accept(OpWith) {
+		exception, ok := p.peek()
+		if !ok || !isLicenseID(exception) {
+			return nil, fmt.Errorf("missing exception after WITH")
+		}
+		p.pos++
+		expr.Exception = exception
+	}
+	return expr, nil
+}
+
+// isLicenseID reports whether tok can be a license or exception identifier:
+// letters, digits, '.', '-', ':' (for DocumentRef) and a trailing '+'.
+func isLicenseID(tok string) bool {
+	switch strings.ToUpper(tok) {
+	case OpAnd, OpOr, OpWith:
+		return false
+	}
+	for i, r := range tok {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == ':':
+		case r == '+' && i == len(tok)-1 && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+var spdxTagPattern = regexp.MustCompile(`SPDX-License-Identifier:\s*(.*)`)
+
+// declaredLicenses returns the licenses declared by the
+// SPDX-License-Identifier tags in content, each with Confidence 1 and the line
+// of its tag. Tags whose expression doesn't parse are ignored. When content has
+// several tags, their expressions are combined with AND.
+func declaredLicenses(content []byte) []License {
+	var expressions []*Expression
+	var licenses []License
+	for i, line := range strings.Split(string(content), "\n") {
+		m := spdxTagPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		// Tags may be followed by the end of a block comment
+		s := strings.TrimSpace(m[1])
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(s, "*/"), "-->"))
+		expr, err := ParseExpression(s)
+		if err != nil {
+			continue
+		}
+		if !slices.ContainsFunc(expressions, func(other *Expression) bool { return other.String() == expr.String() }) {
+			expressions = append(expressions, expr)
+		}
+		for _, simple := range expr.simple() {
+			name := simple.String()
+			if slices.ContainsFunc(licenses, func(l License) bool { return l.Name == name }) {
+				continue
+			}
+			licenses = append(licenses, License{
+				Name:       name,
+				Type:       simple.licenseType(),
+				Confidence: 1,
+				StartLine:  i + 1,
+				EndLine:    i + 1,
+			})
+		}
+	}
+	if len(expressions) == 0 {
+		return nil
+	}
+
+	declared := expressions[0]
+	if len(expressions) > 1 {
+		declared = &Expression{Op: OpAnd, Operands: expressions}
+	}
+	for i := range licenses {
+		licenses[i].Declared = declared.String()
+	}
+	return licenses
+}
+
+// declares reports whether the declared license covers the license the
+// classifier detected as name. The classifier reports deprecated identifiers
+// such as GPL-2.0 where tags use GPL-2.0-only, so those are treated alike.
+func declares(declared License, name string) bool {
+	license, exception, _ := strings.Cut(declared.Name, " "+OpWith+" ")
+	return baseLicenseID(license) == baseLicenseID(name) || exception == name
+}
+
+// baseLicenseID strips the -only, -or-later and + suffixes of license
+// identifiers.
+func baseLicenseID(id string) string {
+	id = strings.TrimSuffix(id, "+")
+	id = strings.TrimSuffix(id, "-only")
+	return strings.TrimSuffix(id, "-or-later")
+}
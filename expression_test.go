@@ -0,0 +1,260 @@
+package licenseplease
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s            string
+		want         string
+		wantLicenses []string
+	}{
+		{"MIT", "MIT", []string{"MIT"}},
+		{"MIT OR Apache-2.0", "MIT OR Apache-2.0", []string{"MIT", "Apache-2.0"}},
+		{"mit or apache-2.0", "mit OR apache-2.0", []string{"mit", "apache-2.0"}},
+		{"MIT AND BSD-3-Clause OR Apache-2.0", "MIT AND BSD-3-Clause OR Apache-2.0", []string{"MIT", "BSD-3-Clause", "Apache-2.0"}},
+		{"MIT AND (BSD-3-Clause OR Apache-2.0)", "MIT AND (BSD-3-Clause OR Apache-2.0)", []string{"MIT", "BSD-3-Clause", "Apache-2.0"}},
+		{"(MIT OR (Apache-2.0 OR MIT))", "MIT OR Apache-2.0 OR MIT", []string{"MIT", "Apache-2.0"}},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", "GPL-2.0-only WITH Classpath-exception-2.0", []string{"GPL-2.0-only WITH Classpath-exception-2.0"}},
+		{"GPL-2.0+ OR LicenseRef-Custom", "GPL-2.0+ OR LicenseRef-Custom", []string{"GPL-2.0+", "LicenseRef-Custom"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			t.Parallel()
+			expr, err := ParseExpression(tt.s)
+			if err != nil {
+				t.Fatalf("ParseExpression() error = %v", err)
+			}
+			if got := expr.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if got := expr.Licenses(); !slices.Equal(got, tt.wantLicenses) {
+				t.Errorf("Licenses() = %v, want %v", got, tt.wantLicenses)
+			}
+		})
+	}
+}
+
+func TestParseExpression_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"", "MIT OR", "AND MIT", "(MIT", "MIT)", "MIT Apache-2.0", "MIT WITH", "MIT\"", "OR"} {
+		if _, err := ParseExpression(s); err == nil {
+			t.Errorf("ParseExpression(%q) expected error", s)
+		}
+	}
+}
+
+func TestDeclaredLicenses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		content      string
+		want         []string
+		wantDeclared string
+		wantLine     int
+	}{
+		{
+			name:         "Single",
+			content:      "Copyright 2024 The Authors\n\nSPDX-License-Identifier: MIT\n",
+			want:         []string{"MIT"},
+			wantDeclared: "MIT",
+			wantLine:     3,
+		},
+		{
+			name:         "Compound",
+			content:      "/* SPDX-License-Identifier: MIT OR Apache-2.0 */\n",
+			want:         []string{"MIT", "Apache-2.0"},
+			wantDeclared: "MIT OR Apache-2.0",
+			wantLine:     1,
+		},
+		{
+			name:         "SeveralTags",
+			content:      "SPDX-License-Identifier: MIT OR Apache-2.0\nSPDX-License-Identifier: BSD-3-Clause\nSPDX-License-Identifier: MIT OR Apache-2.0\n",
+			want:         []string{"MIT", "Apache-2.0", "BSD-3-Clause"},
+			wantDeclared: "(MIT OR Apache-2.0) AND BSD-3-Clause",
+			wantLine:     1,
+		},
+		{
+			name:         "Exception",
+			content:      "// SPDX-License-Identifier: Apache-2.0 WITH LLVM-exception\n",
+			want:         []string{"Apache-2.0 WITH LLVM-exception"},
+			wantDeclared: "Apache-2.0 WITH LLVM-exception",
+			wantLine:     1,
+		},
+		{
+			name:    "Invalid",
+			content: "fmt.Println(\"SPDX-License-Identifier: MIT\")\n",
+		},
+		{
+			name:    "NoTag",
+			content: "MIT License\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			licenses := declaredLicenses([]byte(tt.content))
+			var got []string
+			for _, l := range licenses {
+				got = append(got, l.Name)
+				if l.Declared != tt.wantDeclared || l.Confidence != 1 {
+					t.Errorf("license %s: Declared = %q, Confidence = %v, want %q and 1", l.Name, l.Declared, l.Confidence, tt.wantDeclared)
+				}
+				if l.Type.SPDX() != l.Name {
+					t.Errorf("license %s: Type.SPDX() = %q, want the declared identifier", l.Name, l.Type.SPDX())
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("declaredLicenses() = %v, want %v", got, tt.want)
+			}
+			if len(licenses) > 0 && licenses[0].StartLine != tt.wantLine {
+				t.Errorf("StartLine = %d, want %d", licenses[0].StartLine, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestGoogleLicenseClassifier_Classify_Declared(t *testing.T) {
+	t.Parallel()
+
+	mit, err := os.ReadFile(filepath.Join("testdata", "replace", "fork", "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"dual":       "SPDX-License-Identifier: MIT OR Apache-2.0\n",
+		"consistent": "SPDX-License-Identifier: MIT\n\n" + string(mit),
+		"mismatched": "SPDX-License-Identifier: Apache-2.0\n\n" + string(mit),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	classifier, err := NewGoogleLicenseClassifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	crossChecking := *classifier
+	crossChecking.CrossCheck = true
+
+	tests := []struct {
+		name       string
+		classifier *GoogleLicenseClassifier
+		file       string
+		want       []string
+	}{
+		{"Dual", classifier, "dual", []string{"MIT", "Apache-2.0"}},
+		{"TagIsAuthoritative", classifier, "mismatched", []string{"Apache-2.0"}},
+		{"CrossCheckConsistent", &crossChecking, "consistent", []string{"MIT"}},
+		{"CrossCheckMismatched", &crossChecking, "mismatched", []string{"Apache-2.0", "MIT"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			licenses, err := tt.classifier.Classify(context.Background(), filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatalf("Classify() error = %v", err)
+			}
+			var got []string
+			for _, l := range licenses {
+				got = append(got, l.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_EvaluateExpression(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{
+		Allow:  []string{"MIT", "Apache-2.0", "GPL-2.0-only WITH Classpath-exception-2.0", "LGPL-2.1-only"},
+		Review: []string{"MPL-2.0"},
+		Deny:   []string{"GPL-3.0-only"},
+	}
+	tests := []struct {
+		expr        string
+		want        Decision
+		wantElected string
+	}{
+		{"MIT", Allow, "MIT"},
+		{"GPL-3.0-only OR MIT", Allow, "MIT"},
+		{"MIT OR Apache-2.0", Allow, "MIT"},
+		{"GPL-3.0-only OR MPL-2.0", Review, "MPL-2.0"},
+		{"MIT AND GPL-3.0-only", Deny, "MIT AND GPL-3.0-only"},
+		{"MIT AND (GPL-3.0-only OR Apache-2.0)", Allow, "MIT AND Apache-2.0"},
+		{"(MIT OR GPL-3.0-only) AND (MPL-2.0 OR Apache-2.0)", Allow, "MIT AND Apache-2.0"},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", Allow, "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{"LGPL-2.1-only WITH LLVM-exception", Allow, "LGPL-2.1-only WITH LLVM-exception"},
+		{"GPL-3.0-only WITH GCC-exception-3.1", Deny, "GPL-3.0-only WITH GCC-exception-3.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+			expr, err := ParseExpression(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, elected := policy.EvaluateExpression(expr)
+			if got != tt.want || elected.String() != tt.wantElected {
+				t.Errorf("EvaluateExpression() = %v, %q, want %v, %q", got, elected, tt.want, tt.wantElected)
+			}
+		})
+	}
+}
+
+func TestModuleLicenses(t *testing.T) {
+	t.Parallel()
+
+	dual := Module{Path: "github.com/foo/dual", Version: "v1.0.0"}
+	multi := Module{Path: "github.com/foo/multi", Version: "v1.0.0"}
+	licenseFiles := []LicenseFile{
+		{
+			RelPath: "LICENSE",
+			Module:  dual,
+			Licenses: []License{
+				{Name: "MIT", Declared: "MIT OR Apache-2.0"},
+				{Name: "Apache-2.0", Declared: "MIT OR Apache-2.0"},
+			},
+			Elected: &Expression{License: "MIT"},
+		},
+		{RelPath: "NOTICE", Module: dual},
+		{RelPath: "LICENSE", Module: multi, Licenses: []License{{Name: "MIT"}}},
+		{RelPath: "LICENSE-APACHE", Module: multi, Licenses: []License{{Name: "Apache-2.0"}, {Name: "MIT"}}},
+		{RelPath: "README", Module: Module{Path: "github.com/foo/unknown", Version: "v1.0.0"}},
+	}
+
+	var got []string
+	for _, ml := range ModuleLicenses(licenseFiles) {
+		entry := ml.Module.Path + ": " + ml.Expression.String()
+		if ml.Elected != nil {
+			entry += " => " + ml.Elected.String()
+		}
+		got = append(got, entry)
+	}
+	want := []string{
+		"github.com/foo/dual: MIT OR Apache-2.0 => MIT",
+		"github.com/foo/multi: MIT AND Apache-2.0",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ModuleLicenses() = %v, want %v", got, want)
+	}
+}
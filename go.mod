@@ -5,6 +5,8 @@ go 1.25.5
 require (
 	github.com/alecthomas/kong v1.13.0
 	github.com/google/licenseclassifier/v2 v2.0.0
+	golang.org/x/mod v0.33.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
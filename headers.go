@@ -0,0 +1,170 @@
+package licenseplease
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// HeaderScanner discovers license headers in a module's source files.
+type HeaderScanner interface {
+	Scan(ctx context.Context, module Module) ([]LicenseFile, error)
+}
+
+// HeaderClassifier identifies licenses from the text of a source file header.
+type HeaderClassifier interface {
+	ClassifyHeader(ctx context.Context, header []byte) ([]License, error)
+}
+
+// DefaultHeaderExtensions are the source file extensions SourceHeaderScanner
+// inspects by default.
+var DefaultHeaderExtensions = []string{".go", ".s", ".c", ".h", ".cc", ".cpp", ".proto", ".py", ".sh", ".js", ".ts"}
+
+// maxHeaderSize bounds how much of each source file is read when looking for
+// its leading comment.
+const maxHeaderSize = 16 * 1024
+
+// SourceHeaderScanner implements HeaderScanner by classifying the leading
+// comment of each source file in a module. Only files whose header matches a
+// license are returned.
+type SourceHeaderScanner struct {
+	Classifier HeaderClassifier
+	// Extensions are the file extensions to inspect. Defaults to
+	// DefaultHeaderExtensions.
+	Extensions []string
+}
+
+func (s *SourceHeaderScanner) Scan(ctx context.Context, module Module) ([]LicenseFile, error) {
+	if module.Dir == "" {
+		return nil, nil
+	}
+	extensions := s.Extensions
+	if len(extensions) == 0 {
+		extensions = DefaultHeaderExtensions
+	}
+
+	var headers []LicenseFile
+	err := filepath.WalkDir(module.Dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			// Skip vendor directories
+			if d.Name() == "vendor" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !slices.Contains(extensions, filepath.Ext(path)) {
+			return nil
+		}
+
+		header, err := readHeader(path)
+		if err != nil {
+			return err
+		}
+		if len(header) == 0 {
+			return nil
+		}
+		licenses, err := s.Classifier.ClassifyHeader(ctx, header)
+		if err != nil {
+			return fmt.Errorf("classifying header of %s: %w", path, err)
+		}
+		if len(licenses) == 0 {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(module.Dir, path)
+		headers = append(headers, LicenseFile{
+			Path:     path,
+			RelPath:  relPath,
+			Module:   module,
+			Licenses: licenses,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning headers in module %s: %w", module.Path, err)
+	}
+	return headers, nil
+}
+
+// commentPrefixes are the line comment markers of the supported languages,
+// along with the decoration of block comments.
+var commentPrefixes = []string{"//", "/*", "*/", "*", "#", "--", ";"}
+
+// readHeader returns the text of the comments at the top of the source file
+// at path, with comment markers removed. Reading stops at the first line of
+// code.
+func readHeader(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading source file: %w", err)
+	}
+	defer f.Close()
+
+	var header strings.Builder
+	scanner := bufio.NewScanner(io.LimitReader(f, maxHeaderSize))
+	inBlock := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			header.WriteString("\n")
+			continue
+		}
+		if strings.HasPrefix(line, "#!") {
+			// Interpreter lines of scripts
+			continue
+		}
+		if !inBlock && !slices.ContainsFunc(commentPrefixes, func(p string) bool { return strings.HasPrefix(line, p) }) {
+			break
+		}
+		if strings.HasPrefix(line, "/*") {
+			inBlock = true
+		}
+		if strings.Contains(line, "*/") {
+			inBlock = false
+		}
+		for _, prefix := range commentPrefixes {
+			line = strings.TrimPrefix(line, prefix)
+		}
+		line = strings.TrimSuffix(line, "*/")
+		header.WriteString(strings.TrimSpace(line))
+		header.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading source file %s: %w", path, err)
+	}
+	return []byte(strings.TrimSpace(header.String())), nil
+}
+
+// differingHeaders returns the headers declaring a license that none of the
+// module's top-level license files declare. If the module has no top-level
+// license file, every header is returned.
+func differingHeaders(headers []LicenseFile, licenseFiles []LicenseFile) []LicenseFile {
+	var topLevel []string
+	for _, lf := range licenseFiles {
+		if filepath.Dir(lf.RelPath) != "." {
+			continue
+		}
+		for _, l := range lf.Licenses {
+			topLevel = append(topLevel, l.Name)
+		}
+	}
+
+	var differing []LicenseFile
+	for _, h := range headers {
+		if slices.ContainsFunc(h.Licenses, func(l License) bool { return !slices.Contains(topLevel, l.Name) }) {
+			differing = append(differing, h)
+		}
+	}
+	return differing
+}
@@ -0,0 +1,135 @@
+package licenseplease
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "LineComments",
+			content: "// Copyright 2024 The Authors\n//\n// SPDX-License-Identifier: MIT\n\npackage foo\n\n// Not part of the header\n",
+			want:    "Copyright 2024 The Authors\n\nSPDX-License-Identifier: MIT",
+		},
+		{
+			name:    "BlockComment",
+			content: "/*\n * Licensed under the Apache License, Version 2.0\n * you may not use this file except in compliance\n */\npackage foo\n",
+			want:    "Licensed under the Apache License, Version 2.0\nyou may not use this file except in compliance",
+		},
+		{
+			name:    "HashComments",
+			content: "#!/bin/sh\n# Licensed under MIT\necho hello\n",
+			want:    "Licensed under MIT",
+		},
+		{
+			name:    "NoHeader",
+			content: "package foo\n",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "file.go")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readHeader(path)
+			if err != nil {
+				t.Fatalf("readHeader() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("readHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type mockHeaderClassifier struct{}
+
+// ClassifyHeader recognizes headers of the form "License: <spdx>".
+func (mockHeaderClassifier) ClassifyHeader(ctx context.Context, header []byte) ([]License, error) {
+	_, spdx, ok := strings.Cut(string(header), "License: ")
+	if !ok {
+		return nil, nil
+	}
+	spdx = strings.TrimSpace(spdx)
+	return []License{{Name: spdx, Type: LicenseTypeFromSPDX(spdx)}}, nil
+}
+
+func TestSourceHeaderScanner_Scan(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":              "// License: MIT\npackage main\n",
+		"plain.go":             "package main\n",
+		"third_party/lib.c":    "/* License: GPL-2.0 */\nint x;\n",
+		"README.md":            "License: Apache-2.0\n",
+		"vendor/other/file.go": "// License: GPL-3.0\npackage other\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := &SourceHeaderScanner{Classifier: mockHeaderClassifier{}}
+	headers, err := scanner.Scan(context.Background(), Module{Path: "github.com/foo/bar", Version: "v1.0.0", Dir: dir})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	var got []string
+	for _, h := range headers {
+		got = append(got, h.RelPath+"="+h.Licenses[0].Name)
+	}
+	want := []string{"main.go=MIT", filepath.Join("third_party", "lib.c") + "=GPL-2.0"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Scan() = %v, want %v", got, want)
+	}
+}
+
+func TestDifferingHeaders(t *testing.T) {
+	t.Parallel()
+
+	mod := Module{Path: "github.com/foo/bar", Version: "v1.0.0"}
+	licenseFiles := []LicenseFile{
+		{RelPath: "LICENSE", Module: mod, Licenses: []License{{Name: "MIT"}}},
+		{RelPath: "third_party/LICENSE", Module: mod, Licenses: []License{{Name: "BSD-3-Clause"}}},
+	}
+	headers := []LicenseFile{
+		{RelPath: "main.go", Module: mod, Licenses: []License{{Name: "MIT"}}},
+		{RelPath: "third_party/lib.go", Module: mod, Licenses: []License{{Name: "BSD-3-Clause"}}},
+		{RelPath: "gpl.go", Module: mod, Licenses: []License{{Name: "GPL-3.0"}}},
+	}
+
+	var got []string
+	for _, h := range differingHeaders(headers, licenseFiles) {
+		got = append(got, h.RelPath)
+	}
+	// Only top-level license files define the module's license
+	if want := []string{"third_party/lib.go", "gpl.go"}; !slices.Equal(got, want) {
+		t.Errorf("differingHeaders() = %v, want %v", got, want)
+	}
+
+	if got := differingHeaders(headers, nil); len(got) != len(headers) {
+		t.Errorf("differingHeaders() without license files = %d headers, want all %d", len(got), len(headers))
+	}
+}
@@ -8,11 +8,16 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	classifier "github.com/google/licenseclassifier/v2"
 	"github.com/google/licenseclassifier/v2/assets"
+	"golang.org/x/mod/modfile"
 )
 
 // LicenseType represents a specific license with its compliance requirements.
@@ -140,6 +145,19 @@ func (UnknownLicense) CollectArtifacts(moduleDir string, licenseRelPath string)
 	return []string{licenseRelPath}, nil
 }
 
+// LicenseWithException is a license modified by a license exception, such as
+// "Apache-2.0 WITH LLVM-exception". Exceptions only grant additional
+// permissions, so its artifacts are those of the license itself.
+type LicenseWithException struct {
+	License   LicenseType
+	Exception string
+}
+
+func (l LicenseWithException) SPDX() string { return l.License.SPDX() + " WITH " + l.Exception }
+func (l LicenseWithException) CollectArtifacts(moduleDir string, licenseRelPath string) ([]string, error) {
+	return l.License.CollectArtifacts(moduleDir, licenseRelPath)
+}
+
 // NoticeFile represents a NOTICE or COPYRIGHT file, not a license.
 // These are included because Apache-2.0 requires them and they contain
 // important attribution information.
@@ -171,7 +189,8 @@ func LicenseTypeFromSPDX(spdx string) LicenseType {
 	return UnknownLicense{name: spdx}
 }
 
-// AllowedLicenses returns the set of license SPDX identifiers we accept.
+// AllowedLicenses returns the set of license SPDX identifiers accepted by
+// DefaultPolicy.
 func AllowedLicenses() map[string]bool {
 	allowed := make(map[string]bool)
 	for spdx := range knownLicenses {
@@ -185,12 +204,57 @@ type Module struct {
 	Path    string
 	Version string
 	Dir     string
+	// Replace is the module that replaces this one through a replace
+	// directive, if any. Dir is the replacement's directory. Local filesystem
+	// replacements have a path such as ../fork and no version.
+	Replace *Module
+}
+
+// Local reports whether the module is replaced by a directory on the local
+// filesystem rather than another module version.
+func (m Module) Local() bool {
+	return m.Replace != nil && m.Replace.Version == ""
+}
+
+// source returns the module whose code is actually built: the replacement if
+// there is one, otherwise the module itself.
+func (m Module) source() Module {
+	if m.Replace != nil {
+		return *m.Replace
+	}
+	return m
+}
+
+// PURL returns the package URL identifying the module, e.g.
+// pkg:golang/github.com/foo/bar@v1.2.3. Replaced modules are identified by
+// their replacement, except for local replacements which have no version.
+func (m Module) PURL() string {
+	if m.Local() {
+		return "pkg:golang/" + m.Path
+	}
+	m = m.source()
+	if m.Version == "" {
+		return "pkg:golang/" + m.Path
+	}
+	// '+' is reserved in package URLs, and appears in +incompatible versions
+	return "pkg:golang/" + m.Path + "@" + strings.ReplaceAll(m.Version, "+", "%2B")
 }
 
 // License represents a classified license.
 type License struct {
-	Name string      // SPDX identifier
-	Type LicenseType // The typed license with compliance requirements
+	Name   string      // SPDX identifier
+	Type   LicenseType // The typed license with compliance requirements
+	Waiver *Exception  // The policy exception that allowed this license, if any
+	// Confidence is how closely the text matched the license, from 0 to 1.
+	// Zero means the classifier doesn't report confidence.
+	Confidence float64
+	// StartLine and EndLine are the 1-based range of lines that matched.
+	StartLine int
+	EndLine   int
+	// Declared is the expression of the SPDX-License-Identifier tag that
+	// declared the license. It is empty for licenses detected by the
+	// classifier.
+	Declared string
 }
 
 // LicenseFile represents a discovered license file.
@@ -199,6 +263,65 @@ type LicenseFile struct {
 	RelPath  string
 	Module   Module
 	Licenses []License
+	// Coverage is the fraction of the file's words that are part of a
+	// license match or a copyright notice. Text that doesn't match, such as
+	// extra clauses appended to a standard license, lowers it.
+	Coverage float64
+	// Elected is the branch of the file's Expression that satisfies the
+	// policy, when the expression offers a choice of licenses. It is set by
+	// Policy.Check.
+	Elected *Expression
+	// Artifacts are the files, relative to the module root, that must be
+	// distributed with the module to comply with the file's licenses. They
+	// are set by the Aggregator; see CollectArtifacts.
+	Artifacts []string
+	// Copyrights are the copyright statements found in the file. They are
+	// set by Check; see ReadCopyrights.
+	Copyrights []Copyright
+}
+
+// CollectArtifacts returns the files, relative to the module root, that the
+// file's licenses require to be distributed, as reported by
+// LicenseType.CollectArtifacts. A file without any license, such as a NOTICE
+// file, is distributed as it is.
+func (lf *LicenseFile) CollectArtifacts() ([]string, error) {
+	if len(lf.Licenses) == 0 {
+		return []string{lf.RelPath}, nil
+	}
+	var artifacts []string
+	for _, l := range lf.Licenses {
+		paths, err := l.Type.CollectArtifacts(lf.Module.Dir, lf.RelPath)
+		if err != nil {
+			return nil, fmt.Errorf("collecting artifacts for %s: %w", lf.Module.Path, err)
+		}
+		for _, p := range paths {
+			if !slices.Contains(artifacts, p) {
+				artifacts = append(artifacts, p)
+			}
+		}
+	}
+	return artifacts, nil
+}
+
+// Expression returns the license expression of the file: the expression of
+// its SPDX-License-Identifier tag, combined with AND with any other license
+// found in the file. It is nil if the file has no licenses.
+func (lf *LicenseFile) Expression() *Expression {
+	var exprs []*Expression
+	declared := false
+	for _, l := range lf.Licenses {
+		switch {
+		case l.Name == "":
+		case l.Declared == "":
+			exprs = append(exprs, licenseExpressionOf(l.Name))
+		case !declared:
+			declared = true
+			if expr, err := ParseExpression(l.Declared); err == nil {
+				exprs = append(exprs, expr)
+			}
+		}
+	}
+	return allOf(exprs)
 }
 
 // ModuleResolver lists all modules from a Go project.
@@ -206,6 +329,15 @@ type ModuleResolver interface {
 	Resolve(ctx context.Context, projectDir string) ([]Module, error)
 }
 
+// WorkspaceResolver is a ModuleResolver that can also attribute the modules
+// of a go.work workspace's build list to the workspace modules requiring them.
+type WorkspaceResolver interface {
+	ModuleResolver
+	// RequiredBy returns the workspace modules that require each module path,
+	// directly or indirectly. It returns nil outside a workspace.
+	RequiredBy(ctx context.Context, projectDir string) (map[string][]string, error)
+}
+
 // LicenseFinder discovers license files within a module.
 type LicenseFinder interface {
 	Find(ctx context.Context, module Module) ([]string, error)
@@ -216,34 +348,309 @@ type LicenseClassifier interface {
 	Classify(ctx context.Context, path string) ([]License, error)
 }
 
-// GoModResolver implements ModuleResolver using go mod download.
+// GoModResolver implements ModuleResolver using go mod download. When the
+// project is part of a go.work workspace, dependencies of every workspace
+// module are resolved and attributed to the workspace modules requiring them.
+// Replaced modules, including local filesystem replacements, are resolved to
+// their replacement's directory.
 type GoModResolver struct{}
 
 func (r *GoModResolver) Resolve(ctx context.Context, projectDir string) ([]Module, error) {
-	cmd := exec.CommandContext(ctx, "go", "mod", "download", "-json")
+	gowork, err := goEnv(ctx, projectDir, "GOWORK")
+	if err != nil {
+		return nil, err
+	}
+
+	// In workspace mode, go mod download covers the dependencies of every
+	// module in the workspace
+	downloaded, err := goModDownload(ctx, projectDir, nil)
+	if err != nil {
+		return nil, err
+	}
+	modules, err := goListModules(ctx, projectDir, downloaded)
+	if err != nil {
+		return nil, err
+	}
+
+	if gowork == "" || gowork == "off" {
+		return modules, nil
+	}
+	// The workspace modules are the project itself, not its dependencies
+	members, err := workspaceModules(gowork)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(modules, func(m Module) bool {
+		return slices.Contains(members, m.Path)
+	}), nil
+}
+
+// RequiredBy returns the modules of the go.work workspace that require each
+// module, or nil when the project isn't part of a workspace.
+func (r *GoModResolver) RequiredBy(ctx context.Context, projectDir string) (map[string][]string, error) {
+	gowork, err := goEnv(ctx, projectDir, "GOWORK")
+	if err != nil {
+		return nil, err
+	}
+	if gowork == "" || gowork == "off" {
+		return nil, nil
+	}
+	members, err := workspaceModules(gowork)
+	if err != nil {
+		return nil, err
+	}
+	return workspaceRequirements(ctx, projectDir, members)
+}
+
+// workspaceModules returns the module paths of every module used by the
+// go.work file at gowork.
+func workspaceModules(gowork string) ([]string, error) {
+	content, err := os.ReadFile(gowork)
+	if err != nil {
+		return nil, fmt.Errorf("reading workspace file: %w", err)
+	}
+	work, err := modfile.ParseWork(gowork, content, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing workspace file: %w", err)
+	}
+
+	var members []string
+	for _, use := range work.Use {
+		dir := use.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(gowork), dir)
+		}
+		gomod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("reading workspace module %s: %w", use.Path, err)
+		}
+		members = append(members, modfile.ModulePath(gomod))
+	}
+	return members, nil
+}
+
+// workspaceRequirements walks the module graph from each workspace module and
+// returns, for every module path reached, the workspace modules reaching it.
+func workspaceRequirements(ctx context.Context, projectDir string, members []string) (map[string][]string, error) {
+	cmd := exec.CommandContext(ctx, "go", "mod", "graph")
 	cmd.Dir = projectDir
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("go mod download: %w", err)
+		return nil, fmt.Errorf("go mod graph: %w", err)
+	}
+
+	// Each line is an edge "from to", where nodes are path@version, or just
+	// path for workspace modules
+	edges := make(map[string][]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		from, to, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		edges[from] = append(edges[from], to)
+	}
+
+	requiredBy := make(map[string][]string)
+	for _, member := range members {
+		visited := map[string]bool{member: true}
+		queue := []string{member}
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			for _, next := range edges[node] {
+				if visited[next] {
+					continue
+				}
+				visited[next] = true
+				queue = append(queue, next)
+
+				path, _, _ := strings.Cut(next, "@")
+				if !slices.Contains(requiredBy[path], member) {
+					requiredBy[path] = append(requiredBy[path], member)
+				}
+			}
+		}
+	}
+	return requiredBy, nil
+}
+
+// goEnv returns the value of a go environment variable as seen from dir.
+func goEnv(ctx context.Context, dir string, key string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "env", key)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go env %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// goModule is a module as described by the JSON output of the go command.
+type goModule struct {
+	Path    string    `json:"Path"`
+	Version string    `json:"Version"`
+	Dir     string    `json:"Dir"`
+	Main    bool      `json:"Main"`
+	Replace *goModule `json:"Replace"`
+}
+
+func (m *goModule) module() Module {
+	mod := Module{
+		Path:    m.Path,
+		Version: m.Version,
+		Dir:     m.Dir,
+	}
+	if m.Replace != nil {
+		mod.Replace = &Module{
+			Path:    m.Replace.Path,
+			Version: m.Replace.Version,
+			Dir:     m.Replace.Dir,
+		}
+		mod.Dir = m.Replace.Dir
+	}
+	return mod
+}
+
+// goListModules returns the modules in the build list of the project in dir,
+// keeping only those that were downloaded and local filesystem replacements.
+// Unlike go mod download, go list reports replace directives: download skips
+// local replacements entirely and reports versioned replacements under the
+// replacement's path.
+func goListModules(ctx context.Context, dir string, downloaded []Module) ([]Module, error) {
+	// -mod=readonly overrides a -mod=mod in GOFLAGS, which would otherwise
+	// let go list rewrite the project's go.sum, and which workspace mode rejects
+	cmd := exec.CommandContext(ctx, "go", "list", "-m", "-mod=readonly", "-json", "all")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list -m: %w", err)
+	}
+
+	isDownloaded := make(map[string]bool)
+	for _, m := range downloaded {
+		isDownloaded[m.Path+"@"+m.Version] = true
 	}
 
 	var modules []Module
 	// Parse JSON stream (one object per module)
 	decoder := json.NewDecoder(strings.NewReader(string(output)))
 	for decoder.More() {
-		var m struct {
-			Path    string `json:"Path"`
-			Version string `json:"Version"`
-			Dir     string `json:"Dir"`
+		var m goModule
+		if err := decoder.Decode(&m); err != nil {
+			return nil, fmt.Errorf("parsing module JSON: %w", err)
 		}
+		if m.Main {
+			continue
+		}
+		mod := m.module()
+		source := mod.source()
+		if !mod.Local() && !isDownloaded[source.Path+"@"+source.Version] {
+			continue
+		}
+		modules = append(modules, mod)
+	}
+	return modules, nil
+}
+
+// goModDownload runs go mod download -json in dir for the given module
+// queries (or the whole build list if there are none) and returns the
+// downloaded modules.
+func goModDownload(ctx context.Context, dir string, env []string, queries ...string) ([]Module, error) {
+	args := append([]string{"mod", "download", "-json"}, queries...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go mod download: %w", err)
+	}
+
+	var modules []Module
+	// Parse JSON stream (one object per module)
+	decoder := json.NewDecoder(strings.NewReader(string(output)))
+	for decoder.More() {
+		var m goModule
 		if err := decoder.Decode(&m); err != nil {
 			return nil, fmt.Errorf("parsing module JSON: %w", err)
 		}
-		modules = append(modules, Module{
-			Path:    m.Path,
-			Version: m.Version,
-			Dir:     m.Dir,
-		})
+		modules = append(modules, m.module())
+	}
+	return modules, nil
+}
+
+// GoListResolver implements ModuleResolver using go list -deps. Unlike
+// GoModResolver, it only returns modules providing packages that are linked
+// into the build, excluding test-only dependencies and modules only used on
+// other platforms.
+type GoListResolver struct {
+	// Patterns are the package patterns to resolve dependencies for.
+	// Defaults to ./...
+	Patterns []string
+	// GOOS and GOARCH select the target platform. Default to the host's.
+	GOOS   string
+	GOARCH string
+	// Tags are additional build tags to satisfy.
+	Tags []string
+}
+
+func (r *GoListResolver) Resolve(ctx context.Context, projectDir string) ([]Module, error) {
+	patterns := r.Patterns
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	args := []string{"list", "-deps", "-json=Module,Standard"}
+	if len(r.Tags) > 0 {
+		args = append(args, "-tags="+strings.Join(r.Tags, ","))
+	}
+	args = append(args, patterns...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = projectDir
+	cmd.Env = os.Environ()
+	if r.GOOS != "" {
+		cmd.Env = append(cmd.Env, "GOOS="+r.GOOS)
+	}
+	if r.GOARCH != "" {
+		cmd.Env = append(cmd.Env, "GOARCH="+r.GOARCH)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w", err)
+	}
+	// go list doesn't report module directories when building from the
+	// vendor directory, so point them at the vendored copies
+	vendored, err := vendorMode(ctx, projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []Module
+	seen := make(map[string]bool)
+	// Parse JSON stream (one object per package)
+	decoder := json.NewDecoder(strings.NewReader(string(output)))
+	for decoder.More() {
+		var p struct {
+			Standard bool      `json:"Standard"`
+			Module   *goModule `json:"Module"`
+		}
+		if err := decoder.Decode(&p); err != nil {
+			return nil, fmt.Errorf("parsing package JSON: %w", err)
+		}
+		if p.Standard || p.Module == nil || p.Module.Main || seen[p.Module.Path] {
+			continue
+		}
+		seen[p.Module.Path] = true
+		mod := p.Module.module()
+		if vendored && mod.Dir == "" {
+			// Replaced modules are vendored under their original path
+			mod.Dir = filepath.Join(projectDir, "vendor", filepath.FromSlash(mod.Path))
+			if mod.Replace != nil {
+				mod.Replace.Dir = mod.Dir
+			}
+		}
+		modules = append(modules, mod)
 	}
 	return modules, nil
 }
@@ -285,8 +692,13 @@ func (f *RecursiveLicenseFinder) Find(ctx context.Context, module Module) ([]str
 }
 
 // GoogleLicenseClassifier implements LicenseClassifier using Google's licenseclassifier.
+// Files with an SPDX-License-Identifier tag are classified by the tag alone,
+// which is exact and much faster than matching the text.
 type GoogleLicenseClassifier struct {
 	c *classifier.Classifier
+	// CrossCheck also matches the text of files with an
+	// SPDX-License-Identifier tag, adding any license the tag doesn't declare.
+	CrossCheck bool
 }
 
 func NewGoogleLicenseClassifier() (*GoogleLicenseClassifier, error) {
@@ -302,12 +714,42 @@ func (g *GoogleLicenseClassifier) Classify(ctx context.Context, path string) ([]
 	if err != nil {
 		return nil, fmt.Errorf("reading license file: %w", err)
 	}
+	return g.classify(content, "License"), nil
+}
+
+// ClassifyHeader implements HeaderClassifier. Besides full license texts, it
+// recognizes the standard headers some licenses ask to be put in every file.
+func (g *GoogleLicenseClassifier) ClassifyHeader(ctx context.Context, header []byte) ([]License, error) {
+	return g.classify(header, "License", "Header"), nil
+}
 
+// classify returns the licenses declared by SPDX-License-Identifier tags in
+// content, falling back to matching the text when there are none.
+func (g *GoogleLicenseClassifier) classify(content []byte, matchTypes ...string) []License {
+	declared := declaredLicenses(content)
+	if len(declared) == 0 {
+		return g.match(content, matchTypes...)
+	}
+	if !g.CrossCheck {
+		return declared
+	}
+	licenses := declared
+	for _, l := range g.match(content, matchTypes...) {
+		if !slices.ContainsFunc(declared, func(d License) bool { return declares(d, l.Name) }) {
+			licenses = append(licenses, l)
+		}
+	}
+	return licenses
+}
+
+// match returns the distinct licenses in content whose match type is one of
+// matchTypes.
+func (g *GoogleLicenseClassifier) match(content []byte, matchTypes ...string) []License {
 	results := g.c.Match(content)
 	seen := make(map[string]bool)
 	var licenses []License
 	for _, match := range results.Matches {
-		if match.MatchType != "License" {
+		if !slices.Contains(matchTypes, match.MatchType) {
 			continue
 		}
 		if seen[match.Name] {
@@ -315,11 +757,14 @@ func (g *GoogleLicenseClassifier) Classify(ctx context.Context, path string) ([]
 		}
 		seen[match.Name] = true
 		licenses = append(licenses, License{
-			Name: match.Name,
-			Type: LicenseTypeFromSPDX(match.Name),
+			Name:       match.Name,
+			Type:       LicenseTypeFromSPDX(match.Name),
+			Confidence: match.Confidence,
+			StartLine:  match.StartLine,
+			EndLine:    match.EndLine,
 		})
 	}
-	return licenses, nil
+	return licenses
 }
 
 // Aggregator combines all components to produce a complete license report.
@@ -327,89 +772,513 @@ type Aggregator struct {
 	Resolver   ModuleResolver
 	Finder     LicenseFinder
 	Classifier LicenseClassifier
+	// HeaderScanner optionally scans source files for license headers.
+	HeaderScanner HeaderScanner
+	// Jobs is the maximum number of modules scanned concurrently. Defaults to
+	// GOMAXPROCS. The Finder, Classifier and HeaderScanner must be safe for
+	// concurrent use.
+	Jobs int
 }
 
+// Aggregation is the outcome of scanning every module of a project.
+type Aggregation struct {
+	LicenseFiles []LicenseFile
+	// Unlicensed lists the modules in which no license file was found, or
+	// only NOTICE and COPYRIGHT files without a license.
+	Unlicensed []Module
+	// SourceHeaders lists the source files whose license header declares a
+	// license that their module's top-level license files don't. It is only
+	// set when the Aggregator has a HeaderScanner.
+	SourceHeaders []LicenseFile
+	// RequiredBy maps the path of each module to the workspace modules that
+	// require it. It is only set when the Resolver is a WorkspaceResolver and
+	// the project is part of a go.work workspace.
+	RequiredBy map[string][]string
+}
+
+// Aggregate returns the license files found in every module of the project.
 func (a *Aggregator) Aggregate(ctx context.Context, projectDir string) ([]LicenseFile, error) {
+	aggregation, err := a.Scan(ctx, projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return aggregation.LicenseFiles, nil
+}
+
+// Scan is like Aggregate, but also reports the modules without a license
+// and, with a HeaderScanner, source files whose license differs from their
+// module's. Modules are scanned concurrently, but results
+// keep the order in which the modules were resolved. The first error stops
+// the remaining scans.
+func (a *Aggregator) Scan(ctx context.Context, projectDir string) (*Aggregation, error) {
 	modules, err := a.Resolver.Resolve(ctx, projectDir)
 	if err != nil {
 		return nil, fmt.Errorf("resolving modules: %w", err)
 	}
+	var requiredBy map[string][]string
+	if workspace, ok := a.Resolver.(WorkspaceResolver); ok {
+		if requiredBy, err = workspace.RequiredBy(ctx, projectDir); err != nil {
+			return nil, fmt.Errorf("resolving workspace requirements: %w", err)
+		}
+	}
 
-	var result []LicenseFile
-	for _, mod := range modules {
-		paths, err := a.Finder.Find(ctx, mod)
-		if err != nil {
-			return nil, fmt.Errorf("finding licenses in %s: %w", mod.Path, err)
+	jobs := a.Jobs
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+
+	// Scan modules concurrently, stopping the others at the first error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		scans    = make([]moduleScan, len(modules))
+		indexes  = make(chan int)
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for range min(jobs, len(modules)) {
+		wg.Go(func() {
+			for i := range indexes {
+				scan, err := a.scanModule(ctx, modules[i], modules)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				scans[i] = scan
+			}
+		})
+	}
+feed:
+	for i := range modules {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
 		}
+	}
+	close(indexes)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-		for _, path := range paths {
-			licenses, err := a.Classifier.Classify(ctx, path)
-			if err != nil {
-				return nil, fmt.Errorf("classifying %s: %w", path, err)
+	// Merge in the order the modules were resolved, so output is deterministic
+	aggregation := &Aggregation{}
+	for i, scan := range scans {
+		if paths, ok := requiredBy[modules[i].Path]; ok {
+			if aggregation.RequiredBy == nil {
+				aggregation.RequiredBy = make(map[string][]string)
 			}
+			aggregation.RequiredBy[modules[i].Path] = paths
+		}
+		aggregation.LicenseFiles = append(aggregation.LicenseFiles, scan.licenseFiles...)
+		if !scan.licensed() {
+			aggregation.Unlicensed = append(aggregation.Unlicensed, modules[i])
+		}
+		aggregation.SourceHeaders = append(aggregation.SourceHeaders, scan.headers...)
+	}
+	return aggregation, nil
+}
+
+// moduleScan is the outcome of scanning a single module.
+type moduleScan struct {
+	licenseFiles []LicenseFile
+	headers      []LicenseFile
+}
+
+// licensed reports whether the module has a license file: one in which a
+// license was found, or one that could not be classified, which the policy
+// evaluates as unclassified. NOTICE and COPYRIGHT files alone grant no
+// license.
+func (s moduleScan) licensed() bool {
+	return slices.ContainsFunc(s.licenseFiles, func(lf LicenseFile) bool {
+		return len(lf.Licenses) > 0 || !lf.IsNotice()
+	})
+}
+
+// scanModule finds and classifies the license files of mod and, with a
+// HeaderScanner, the source file headers differing from them. modules are all
+// the project's modules, so that files of nested modules can be skipped.
+func (a *Aggregator) scanModule(ctx context.Context, mod Module, modules []Module) (moduleScan, error) {
+	var scan moduleScan
+	paths, err := a.Finder.Find(ctx, mod)
+	if err != nil {
+		return scan, fmt.Errorf("finding licenses in %s: %w", mod.Path, err)
+	}
+
+	nested := nestedModuleDirs(mod, modules)
+	inNested := func(path string) bool {
+		// Files inside a nested module belong to that module alone
+		return slices.ContainsFunc(nested, func(dir string) bool { return isWithin(path, dir) })
+	}
+	for _, path := range paths {
+		if inNested(path) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return scan, err
+		}
 
-			relPath, _ := filepath.Rel(mod.Dir, path)
-			result = append(result, LicenseFile{
-				Path:     path,
-				RelPath:  relPath,
-				Module:   mod,
-				Licenses: licenses,
-			})
+		licenses, err := a.Classifier.Classify(ctx, path)
+		if err != nil {
+			return scan, fmt.Errorf("classifying %s: %w", path, err)
+		}
+
+		coverage, err := matchCoverage(path, licenses)
+		if err != nil {
+			return scan, err
 		}
+
+		relPath, _ := filepath.Rel(mod.Dir, path)
+		lf := LicenseFile{
+			Path:     path,
+			RelPath:  relPath,
+			Module:   mod,
+			Licenses: licenses,
+			Coverage: coverage,
+		}
+		if lf.Artifacts, err = lf.CollectArtifacts(); err != nil {
+			return scan, err
+		}
+
+		scan.licenseFiles = append(scan.licenseFiles, lf)
 	}
-	return result, nil
+
+	if a.HeaderScanner != nil {
+		headers, err := a.HeaderScanner.Scan(ctx, mod)
+		if err != nil {
+			return scan, fmt.Errorf("scanning headers in %s: %w", mod.Path, err)
+		}
+		headers = slices.DeleteFunc(headers, func(h LicenseFile) bool { return inNested(h.Path) })
+		scan.headers = differingHeaders(headers, scan.licenseFiles)
+	}
+	return scan, nil
+}
+
+// matchCoverage returns the fraction of words in the file at path that are on
+// lines matched by one of the licenses or on copyright lines. It is zero when
+// there are no licenses or the classifier doesn't report line ranges, and
+// licenses declared by SPDX-License-Identifier tags don't count.
+func matchCoverage(path string, licenses []License) (float64, error) {
+	licenses = slices.DeleteFunc(slices.Clone(licenses), func(l License) bool { return l.Declared != "" })
+	if !slices.ContainsFunc(licenses, func(l License) bool { return l.EndLine > 0 }) {
+		return 0, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("reading license file: %w", err)
+	}
+	total := len(strings.Fields(string(content)))
+	if total == 0 {
+		return 0, nil
+	}
+	unmatched := 0
+	for _, line := range unmatchedLines(content, licenses) {
+		unmatched += len(strings.Fields(line))
+	}
+	return float64(total-unmatched) / float64(total), nil
+}
+
+var copyrightLinePattern = regexp.MustCompile(`(?i)^\s*(copyright|\(c\)|©)`)
+
+// unmatchedLines returns the non-blank lines of content that are neither
+// within one of the licenses' matched line ranges nor copyright lines.
+func unmatchedLines(content []byte, licenses []License) []string {
+	var unmatched []string
+	for i, line := range strings.Split(string(content), "\n") {
+		n := i + 1
+		if strings.TrimSpace(line) == "" || copyrightLinePattern.MatchString(line) {
+			continue
+		}
+		if slices.ContainsFunc(licenses, func(l License) bool { return n >= l.StartLine && n <= l.EndLine }) {
+			continue
+		}
+		unmatched = append(unmatched, strings.TrimSpace(line))
+	}
+	return unmatched
+}
+
+// nestedModuleDirs returns the directories of other modules that live inside
+// mod's directory, as happens with vendored modules such as example.com/foo
+// and example.com/foo/bar.
+func nestedModuleDirs(mod Module, modules []Module) []string {
+	if mod.Dir == "" {
+		return nil
+	}
+	var dirs []string
+	for _, other := range modules {
+		if other.Dir != "" && other.Dir != mod.Dir && isWithin(other.Dir, mod.Dir) {
+			dirs = append(dirs, other.Dir)
+		}
+	}
+	return dirs
+}
+
+// isWithin reports whether path is inside dir.
+func isWithin(path string, dir string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// IsNotice reports whether the file is a NOTICE or COPYRIGHT file rather
+// than a license.
+func (lf *LicenseFile) IsNotice() bool {
+	base := strings.ToUpper(strings.TrimSuffix(lf.RelPath, filepath.Ext(lf.RelPath)))
+	return strings.Contains(base, "NOTICE") || strings.Contains(base, "COPYRIGHT")
 }
 
-// LicenseURL returns a URL to view the license on pkg.go.dev.
+// LicenseURL returns a URL to view the license on pkg.go.dev. Replaced
+// modules link to their replacement. Local filesystem replacements aren't
+// published, so their URL is empty.
 func (lf *LicenseFile) LicenseURL() string {
-	return fmt.Sprintf("https://pkg.go.dev/%s@%s?tab=licenses", lf.Module.Path, lf.Module.Version)
+	if lf.Module.Local() {
+		return ""
+	}
+	source := lf.Module.source()
+	return fmt.Sprintf("https://pkg.go.dev/%s@%s?tab=licenses", source.Path, source.Version)
 }
 
 // Result contains the output of a license scan.
 type Result struct {
 	LicenseFiles []LicenseFile
+	// Unlicensed lists the modules in which no license file was found, or
+	// only NOTICE and COPYRIGHT files without a license.
+	Unlicensed []Module
+	// SourceHeaders lists source files whose license header differs from
+	// their module's license. It is only set when scanning with
+	// WithSourceHeaders.
+	SourceHeaders []LicenseFile
+	// RequiredBy maps the path of each module to the modules of the go.work
+	// workspace that require it. It is only set when scanning a workspace.
+	RequiredBy map[string][]string
+	// Violations lists licenses the policy denies.
+	Violations []PolicyViolation
+	// NeedsReview lists licenses the policy allows only after human review.
+	NeedsReview []PolicyViolation
+	// Modules lists the license expression of every module with a
+	// classified license, and the branch elected where it offers a choice.
+	Modules []ModuleLicense
 }
 
-// Run scans a Go project for dependencies, finds their licenses, validates them
-// against the allowed list, and returns the results sorted by module path.
-func Run(ctx context.Context, projectDir string) (*Result, error) {
-	classifier, err := NewGoogleLicenseClassifier()
+// Option configures Run and Check.
+type Option func(*options)
+
+type options struct {
+	policy        *Policy
+	policyFile    string
+	resolver      ModuleResolver
+	minConfidence float64
+	headers       bool
+	crossCheck    bool
+	jobs          int
+	cacheDir      string
+}
+
+// WithPolicy evaluates dependencies against the given policy.
+func WithPolicy(policy *Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithPolicyFile loads the policy to evaluate dependencies against from path.
+func WithPolicyFile(path string) Option {
+	return func(o *options) {
+		o.policyFile = path
+	}
+}
+
+// WithResolver resolves dependencies using the given resolver instead of
+// GoModResolver, or VendorResolver when the project builds with -mod=vendor.
+func WithResolver(resolver ModuleResolver) Option {
+	return func(o *options) {
+		o.resolver = resolver
+	}
+}
+
+// WithMinConfidence overrides the policy's MinConfidence, the classifier
+// confidence below which a license match needs review.
+func WithMinConfidence(confidence float64) Option {
+	return func(o *options) {
+		o.minConfidence = confidence
+	}
+}
+
+// WithSourceHeaders also scans the leading comments of source files for
+// license headers, reporting files whose license differs from their module's
+// license and evaluating them against the policy.
+func WithSourceHeaders() Option {
+	return func(o *options) {
+		o.headers = true
+	}
+}
+
+// WithCrossCheck also runs the classifier on files that declare their license
+// with an SPDX-License-Identifier tag. Licenses it detects that the tag
+// doesn't declare are evaluated against the policy, and the file needs review.
+func WithCrossCheck() Option {
+	return func(o *options) {
+		o.crossCheck = true
+	}
+}
+
+// WithJobs scans up to n modules concurrently instead of GOMAXPROCS.
+func WithJobs(n int) Option {
+	return func(o *options) {
+		o.jobs = n
+	}
+}
+
+// WithCache caches classification results in dir, reusing them in later
+// scans. See DefaultCacheDir.
+func WithCache(dir string) Option {
+	return func(o *options) {
+		o.cacheDir = dir
+	}
+}
+
+// resolvePolicy picks the policy for a scan. An explicit policy wins, then an
+// explicit policy file, then DefaultPolicyFile in the project root, and
+// finally DefaultPolicy.
+func (o *options) resolvePolicy(projectDir string) (*Policy, error) {
+	if o.policy != nil {
+		return o.policy, nil
+	}
+	if o.policyFile != "" {
+		return LoadPolicy(o.policyFile)
+	}
+	path := filepath.Join(projectDir, DefaultPolicyFile)
+	if _, err := os.Stat(path); err == nil {
+		return LoadPolicy(path)
+	}
+	return DefaultPolicy(), nil
+}
+
+// Check scans a Go project for dependencies, finds their licenses and evaluates
+// them against the license policy. Unlike Run, policy violations are recorded
+// on the Result rather than returned as an error. Modules without any license
+// file are recorded as unlicensed and, unless the policy allows it, reported
+// as violations. License files and modules are sorted by module path, and
+// license files carry the copyright statements found in them.
+func Check(ctx context.Context, projectDir string, opts ...Option) (*Result, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	policy, err := o.resolvePolicy(projectDir)
 	if err != nil {
-		return nil, fmt.Errorf("creating classifier: %w", err)
+		return nil, err
+	}
+	if o.minConfidence != 0 {
+		if o.minConfidence < 0 || o.minConfidence > 1 {
+			return nil, fmt.Errorf("minimum confidence must be between 0 and 1, got %v", o.minConfidence)
+		}
+		overridden := *policy
+		overridden.MinConfidence = o.minConfidence
+		policy = &overridden
+	}
+
+	var classifier interface {
+		LicenseClassifier
+		HeaderClassifier
+	}
+	if o.cacheDir != "" {
+		classifier = &CachingClassifier{Dir: o.cacheDir, CrossCheck: o.crossCheck}
+	} else {
+		google, err := NewGoogleLicenseClassifier()
+		if err != nil {
+			return nil, fmt.Errorf("creating classifier: %w", err)
+		}
+		google.CrossCheck = o.crossCheck
+		classifier = google
+	}
+
+	resolver := o.resolver
+	if resolver == nil {
+		vendored, err := vendorMode(ctx, projectDir)
+		if err != nil {
+			return nil, err
+		}
+		if vendored {
+			resolver = &VendorResolver{}
+		} else {
+			resolver = &GoModResolver{}
+		}
 	}
 
 	aggregator := &Aggregator{
-		Resolver:   &GoModResolver{},
+		Resolver:   resolver,
 		Finder:     &RecursiveLicenseFinder{},
 		Classifier: classifier,
+		Jobs:       o.jobs,
+	}
+	if o.headers {
+		aggregator.HeaderScanner = &SourceHeaderScanner{Classifier: classifier}
 	}
 
-	licenseFiles, err := aggregator.Aggregate(ctx, projectDir)
+	aggregation, err := aggregator.Scan(ctx, projectDir)
 	if err != nil {
 		return nil, err
 	}
+	licenseFiles := aggregation.LicenseFiles
+	for i := range licenseFiles {
+		if licenseFiles[i].Copyrights, err = ReadCopyrights(licenseFiles[i].Path); err != nil {
+			return nil, err
+		}
+	}
 
 	// Sort by module path for consistent output
+	sortLicenseFiles(licenseFiles)
+	sortLicenseFiles(aggregation.SourceHeaders)
+
+	sort.Slice(aggregation.Unlicensed, func(i, j int) bool {
+		return aggregation.Unlicensed[i].Path < aggregation.Unlicensed[j].Path
+	})
+
+	now := time.Now()
+	violations, review := policy.Check(licenseFiles, now)
+	unlicensedViolations, unlicensedReview := policy.CheckUnlicensed(aggregation.Unlicensed, now)
+	headerViolations, headerReview := policy.Check(aggregation.SourceHeaders, now)
+	return &Result{
+		LicenseFiles:  licenseFiles,
+		Unlicensed:    aggregation.Unlicensed,
+		SourceHeaders: aggregation.SourceHeaders,
+		RequiredBy:    aggregation.RequiredBy,
+		Violations:    slices.Concat(violations, unlicensedViolations, headerViolations),
+		NeedsReview:   slices.Concat(review, unlicensedReview, headerReview),
+		Modules:       ModuleLicenses(licenseFiles),
+	}, nil
+}
+
+// sortLicenseFiles sorts license files by module path, then by path within
+// the module.
+func sortLicenseFiles(licenseFiles []LicenseFile) {
 	sort.Slice(licenseFiles, func(i, j int) bool {
 		if licenseFiles[i].Module.Path != licenseFiles[j].Module.Path {
 			return licenseFiles[i].Module.Path < licenseFiles[j].Module.Path
 		}
 		return licenseFiles[i].RelPath < licenseFiles[j].RelPath
 	})
+}
 
-	// Check for disallowed licenses
-	allowed := AllowedLicenses()
-	var disallowed []string
-	for _, lf := range licenseFiles {
-		for _, l := range lf.Licenses {
-			if !allowed[l.Name] && l.Name != "" {
-				disallowed = append(disallowed, fmt.Sprintf("%s@%s: %s (%s)", lf.Module.Path, lf.Module.Version, l.Name, lf.RelPath))
-			}
-		}
+// Run scans a Go project for dependencies, finds their licenses, validates them
+// against the license policy, and returns the results sorted by module path.
+// If any dependency violates the policy, Run returns the full Result together
+// with a *PolicyError listing the violations.
+func Run(ctx context.Context, projectDir string, opts ...Option) (*Result, error) {
+	result, err := Check(ctx, projectDir, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if len(disallowed) > 0 {
-		return nil, fmt.Errorf("found %d dependencies with disallowed licenses:\n  %s", len(disallowed), strings.Join(disallowed, "\n  "))
+	if len(result.Violations) > 0 {
+		return result, &PolicyError{Violations: result.Violations}
 	}
-
-	return &Result{LicenseFiles: licenseFiles}, nil
+	return result, nil
 }
@@ -2,9 +2,14 @@ package licenseplease
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestRecursiveLicenseFinder_Find(t *testing.T) {
@@ -191,6 +196,15 @@ func (m *mockResolver) Resolve(ctx context.Context, projectDir string) ([]Module
 	return m.modules, m.err
 }
 
+type mockWorkspaceResolver struct {
+	mockResolver
+	requiredBy map[string][]string
+}
+
+func (m *mockWorkspaceResolver) RequiredBy(ctx context.Context, projectDir string) (map[string][]string, error) {
+	return m.requiredBy, nil
+}
+
 type mockFinder struct {
 	paths map[string][]string // module path -> license paths
 	err   error
@@ -260,6 +274,204 @@ func TestAggregator_Aggregate(t *testing.T) {
 	}
 }
 
+func TestAggregator_Scan_Artifacts(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"LICENSE", "NOTICE"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mod := Module{Path: "github.com/foo/apache", Version: "v1.0.0", Dir: dir}
+	licensePath := filepath.Join(dir, "LICENSE")
+	noticePath := filepath.Join(dir, "NOTICE")
+
+	aggregator := &Aggregator{
+		Resolver: &mockResolver{modules: []Module{mod}},
+		Finder:   &mockFinder{paths: map[string][]string{mod.Path: {licensePath, noticePath}}},
+		Classifier: &mockClassifier{licenses: map[string][]License{
+			licensePath: {{Name: "Apache-2.0", Type: Apache2{}}},
+		}},
+	}
+
+	licenseFiles, err := aggregator.Aggregate(context.Background(), "/project")
+	if err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+	if len(licenseFiles) != 2 {
+		t.Fatalf("Aggregate() = %d license files, want 2", len(licenseFiles))
+	}
+	if want := []string{"LICENSE", "NOTICE"}; !slices.Equal(licenseFiles[0].Artifacts, want) {
+		t.Errorf("LICENSE artifacts = %v, want %v", licenseFiles[0].Artifacts, want)
+	}
+	// Files without a license are distributed as they are
+	if want := []string{"NOTICE"}; !slices.Equal(licenseFiles[1].Artifacts, want) {
+		t.Errorf("NOTICE artifacts = %v, want %v", licenseFiles[1].Artifacts, want)
+	}
+}
+
+func TestAggregator_Scan_Unlicensed(t *testing.T) {
+	t.Parallel()
+
+	modules := []Module{
+		{Path: "github.com/foo/bar", Version: "v1.0.0", Dir: "/tmp/mod/foo/bar"},
+		{Path: "github.com/no/license", Version: "v1.0.0", Dir: "/tmp/mod/no/license"},
+		{Path: "github.com/no/source", Version: "v1.0.0"},
+	}
+
+	finderPaths := map[string][]string{
+		"github.com/foo/bar": {"/tmp/mod/foo/bar/LICENSE"},
+	}
+
+	aggregator := &Aggregator{
+		Resolver:   &mockResolver{modules: modules},
+		Finder:     &mockFinder{paths: finderPaths},
+		Classifier: &mockClassifier{},
+	}
+
+	aggregation, err := aggregator.Scan(context.Background(), "/project")
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	if len(aggregation.LicenseFiles) != 1 {
+		t.Errorf("expected 1 license file, got %d", len(aggregation.LicenseFiles))
+	}
+	var unlicensed []string
+	for _, m := range aggregation.Unlicensed {
+		unlicensed = append(unlicensed, m.Path)
+	}
+	if want := []string{"github.com/no/license", "github.com/no/source"}; !slices.Equal(unlicensed, want) {
+		t.Errorf("unlicensed = %v, want %v", unlicensed, want)
+	}
+}
+
+func TestAggregator_Scan_NoticeOnly(t *testing.T) {
+	t.Parallel()
+
+	modules := []Module{
+		{Path: "github.com/notice/only", Version: "v1.0.0", Dir: "/tmp/mod/notice/only"},
+		{Path: "github.com/copyright/only", Version: "v1.0.0", Dir: "/tmp/mod/copyright/only"},
+		{Path: "github.com/notice/licensed", Version: "v1.0.0", Dir: "/tmp/mod/notice/licensed"},
+	}
+
+	finderPaths := map[string][]string{
+		"github.com/notice/only":     {"/tmp/mod/notice/only/NOTICE"},
+		"github.com/copyright/only":  {"/tmp/mod/copyright/only/COPYRIGHT"},
+		"github.com/notice/licensed": {"/tmp/mod/notice/licensed/LICENSE", "/tmp/mod/notice/licensed/NOTICE"},
+	}
+	classifierLicenses := map[string][]License{
+		"/tmp/mod/notice/licensed/LICENSE": {{Name: "Apache-2.0", Type: Apache2{}}},
+	}
+
+	aggregator := &Aggregator{
+		Resolver:   &mockResolver{modules: modules},
+		Finder:     &mockFinder{paths: finderPaths},
+		Classifier: &mockClassifier{licenses: classifierLicenses},
+	}
+
+	aggregation, err := aggregator.Scan(context.Background(), "/project")
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	// A NOTICE or COPYRIGHT file without a license grants no rights
+	var unlicensed []string
+	for _, m := range aggregation.Unlicensed {
+		unlicensed = append(unlicensed, m.Path)
+	}
+	if want := []string{"github.com/notice/only", "github.com/copyright/only"}; !slices.Equal(unlicensed, want) {
+		t.Errorf("unlicensed = %v, want %v", unlicensed, want)
+	}
+	// The notices are still reported
+	if len(aggregation.LicenseFiles) != 4 {
+		t.Errorf("expected 4 license files, got %d", len(aggregation.LicenseFiles))
+	}
+
+	violations, _ := DefaultPolicy().CheckUnlicensed(aggregation.Unlicensed, time.Now())
+	if len(violations) != 2 {
+		t.Errorf("expected a violation for each module with only a notice, got %v", violations)
+	}
+}
+
+type mockHeaderScanner struct {
+	headers map[string][]LicenseFile // module path -> headers
+}
+
+func (m *mockHeaderScanner) Scan(ctx context.Context, module Module) ([]LicenseFile, error) {
+	return m.headers[module.Path], nil
+}
+
+func TestAggregator_Scan_SourceHeaders(t *testing.T) {
+	t.Parallel()
+
+	mod := Module{Path: "github.com/foo/bar", Version: "v1.0.0", Dir: "/tmp/mod/foo/bar"}
+	nested := Module{Path: "github.com/foo/bar/v2", Version: "v2.0.0", Dir: "/tmp/mod/foo/bar/v2"}
+
+	aggregator := &Aggregator{
+		Resolver: &mockResolver{modules: []Module{mod, nested}},
+		Finder:   &mockFinder{paths: map[string][]string{"github.com/foo/bar": {"/tmp/mod/foo/bar/LICENSE"}}},
+		Classifier: &mockClassifier{licenses: map[string][]License{
+			"/tmp/mod/foo/bar/LICENSE": {{Name: "MIT", Type: MIT{}}},
+		}},
+		HeaderScanner: &mockHeaderScanner{headers: map[string][]LicenseFile{
+			"github.com/foo/bar": {
+				{Path: "/tmp/mod/foo/bar/main.go", RelPath: "main.go", Module: mod, Licenses: []License{{Name: "MIT"}}},
+				{Path: "/tmp/mod/foo/bar/gpl.go", RelPath: "gpl.go", Module: mod, Licenses: []License{{Name: "GPL-3.0"}}},
+				{Path: "/tmp/mod/foo/bar/v2/gpl.go", RelPath: "v2/gpl.go", Module: mod, Licenses: []License{{Name: "GPL-3.0"}}},
+			},
+		}},
+	}
+
+	aggregation, err := aggregator.Scan(context.Background(), "/project")
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	// Headers matching the module's license, or inside nested modules, aren't reported
+	if len(aggregation.SourceHeaders) != 1 || aggregation.SourceHeaders[0].RelPath != "gpl.go" {
+		t.Errorf("SourceHeaders = %+v, want only gpl.go", aggregation.SourceHeaders)
+	}
+}
+
+func TestMatchCoverage(t *testing.T) {
+	t.Parallel()
+
+	content := `MIT License
+
+Copyright (c) 2024 Example Authors
+
+Permission is hereby granted, free of charge,
+to any person obtaining a copy of this software.
+
+Additional restriction: not for military use.
+`
+	path := filepath.Join(t.TempDir(), "LICENSE")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	licenses := []License{{Name: "MIT", StartLine: 5, EndLine: 6}}
+	if got, want := unmatchedLines([]byte(content), licenses), []string{"MIT License", "Additional restriction: not for military use."}; !slices.Equal(got, want) {
+		t.Errorf("unmatchedLines() = %q, want %q", got, want)
+	}
+
+	coverage, err := matchCoverage(path, licenses)
+	if err != nil {
+		t.Fatalf("matchCoverage() error = %v", err)
+	}
+	// 8 of the 29 words are unmatched
+	if want := 21.0 / 29.0; coverage != want {
+		t.Errorf("matchCoverage() = %v, want %v", coverage, want)
+	}
+
+	// Classifiers that don't report line ranges have no coverage
+	if coverage, err := matchCoverage(path, []License{{Name: "MIT"}}); err != nil || coverage != 0 {
+		t.Errorf("matchCoverage() without line ranges = %v, %v, want 0", coverage, err)
+	}
+}
+
 func TestAggregator_Aggregate_ResolverError(t *testing.T) {
 	t.Parallel()
 
@@ -275,6 +487,88 @@ func TestAggregator_Aggregate_ResolverError(t *testing.T) {
 	}
 }
 
+// slowClassifier classifies every file as MIT, taking longer for files of
+// modules resolved earlier so that scans finish out of order.
+type slowClassifier struct {
+	delays map[string]time.Duration // file path -> delay
+}
+
+func (m *slowClassifier) Classify(ctx context.Context, path string) ([]License, error) {
+	time.Sleep(m.delays[path])
+	return []License{{Name: "MIT", Type: MIT{}}}, nil
+}
+
+func TestAggregator_Scan_Jobs(t *testing.T) {
+	t.Parallel()
+
+	var modules []Module
+	paths := make(map[string][]string)
+	delays := make(map[string]time.Duration)
+	for i := range 20 {
+		mod := Module{Path: fmt.Sprintf("github.com/foo/mod%02d", i), Version: "v1.0.0", Dir: fmt.Sprintf("/tmp/mod/foo/mod%02d", i)}
+		modules = append(modules, mod)
+		path := mod.Dir + "/LICENSE"
+		paths[mod.Path] = []string{path}
+		delays[path] = time.Duration(20-i) * time.Millisecond
+	}
+
+	for _, jobs := range []int{1, 4, 0} {
+		aggregator := &Aggregator{
+			Resolver:   &mockResolver{modules: modules},
+			Finder:     &mockFinder{paths: paths},
+			Classifier: &slowClassifier{delays: delays},
+			Jobs:       jobs,
+		}
+		licenseFiles, err := aggregator.Aggregate(context.Background(), "/project")
+		if err != nil {
+			t.Fatalf("Aggregate() with %d jobs error = %v", jobs, err)
+		}
+		if len(licenseFiles) != len(modules) {
+			t.Fatalf("Aggregate() with %d jobs = %d license files, want %d", jobs, len(licenseFiles), len(modules))
+		}
+		for i, lf := range licenseFiles {
+			if lf.Module.Path != modules[i].Path {
+				t.Errorf("Aggregate() with %d jobs: license file %d is from %s, want %s", jobs, i, lf.Module.Path, modules[i].Path)
+			}
+		}
+	}
+}
+
+// blockingFinder fails for the module named bad, and blocks finding the
+// licenses of any other module until the context is cancelled.
+type blockingFinder struct {
+	bad string
+}
+
+func (m *blockingFinder) Find(ctx context.Context, module Module) ([]string, error) {
+	if module.Path == m.bad {
+		return nil, os.ErrPermission
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestAggregator_Scan_CancelsOnError(t *testing.T) {
+	t.Parallel()
+
+	modules := []Module{
+		{Path: "github.com/foo/slow", Version: "v1.0.0", Dir: "/tmp/mod/foo/slow"},
+		{Path: "github.com/foo/bad", Version: "v1.0.0", Dir: "/tmp/mod/foo/bad"},
+		{Path: "github.com/foo/other", Version: "v1.0.0", Dir: "/tmp/mod/foo/other"},
+	}
+	aggregator := &Aggregator{
+		Resolver:   &mockResolver{modules: modules},
+		Finder:     &blockingFinder{bad: "github.com/foo/bad"},
+		Classifier: &mockClassifier{},
+		Jobs:       2,
+	}
+
+	_, err := aggregator.Scan(context.Background(), "/project")
+	if !errors.Is(err, os.ErrPermission) {
+		t.Errorf("Scan() error = %v, want %v", err, os.ErrPermission)
+	}
+}
+
 func TestLicenseFilePattern(t *testing.T) {
 	t.Parallel()
 
@@ -311,3 +605,89 @@ func TestLicenseFilePattern(t *testing.T) {
 		})
 	}
 }
+
+func TestModule_PURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		module Module
+		want   string
+	}{
+		{Module{Path: "github.com/foo/bar", Version: "v1.2.3"}, "pkg:golang/github.com/foo/bar@v1.2.3"},
+		{Module{Path: "github.com/foo/bar", Version: "v2.0.0+incompatible"}, "pkg:golang/github.com/foo/bar@v2.0.0%2Bincompatible"},
+		{Module{Path: "github.com/foo/bar"}, "pkg:golang/github.com/foo/bar"},
+		{Module{Path: "github.com/foo/baz", Version: "v1.0.0", Replace: &Module{Path: "github.com/me/baz", Version: "v1.0.1"}}, "pkg:golang/github.com/me/baz@v1.0.1"},
+		{Module{Path: "github.com/foo/qux", Version: "v1.0.0", Replace: &Module{Path: "../qux"}}, "pkg:golang/github.com/foo/qux"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.module.PURL(); got != tt.want {
+				t.Errorf("PURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspaceModules(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"go.work":         "go 1.21\n\nuse (\n\t./a\n\t./nested/b\n)\n",
+		"a/go.mod":        "module example.com/a\n\ngo 1.21\n",
+		"nested/b/go.mod": "module example.com/b\n\ngo 1.21\n",
+		"unused/c/go.mod": "module example.com/c\n\ngo 1.21\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	members, err := workspaceModules(filepath.Join(tmpDir, "go.work"))
+	if err != nil {
+		t.Fatalf("workspaceModules() error = %v", err)
+	}
+	if want := []string{"example.com/a", "example.com/b"}; !slices.Equal(members, want) {
+		t.Errorf("workspaceModules() = %v, want %v", members, want)
+	}
+}
+
+func TestAggregator_Scan_Workspace(t *testing.T) {
+	t.Parallel()
+
+	modules := []Module{
+		{Path: "github.com/test/shared", Version: "v1.0.0", Dir: "/tmp/mod/test/shared"},
+		{Path: "github.com/test/tool", Version: "v1.0.0", Dir: "/tmp/mod/test/tool"},
+	}
+	requiredBy := map[string][]string{
+		"github.com/test/shared": {"example.com/a", "example.com/b"},
+		"github.com/test/tool":   {"example.com/b"},
+		// Only modules in the build list are attributed
+		"golang.org/toolchain": {"example.com/a"},
+	}
+
+	aggregator := &Aggregator{
+		Resolver:   &mockWorkspaceResolver{mockResolver: mockResolver{modules: modules}, requiredBy: requiredBy},
+		Finder:     &mockFinder{paths: map[string][]string{}},
+		Classifier: &mockClassifier{},
+	}
+
+	aggregation, err := aggregator.Scan(context.Background(), "/project")
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	want := map[string][]string{
+		"github.com/test/shared": {"example.com/a", "example.com/b"},
+		"github.com/test/tool":   {"example.com/b"},
+	}
+	if !reflect.DeepEqual(aggregation.RequiredBy, want) {
+		t.Errorf("RequiredBy = %v, want %v", aggregation.RequiredBy, want)
+	}
+}
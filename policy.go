@@ -0,0 +1,639 @@
+package licenseplease
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPolicyFile is the policy file Run looks for in the project root
+// when no policy has been given explicitly.
+const DefaultPolicyFile = ".license-please.yaml"
+
+// Decision is the outcome of evaluating a license against a Policy.
+type Decision int
+
+const (
+	// Allow means the license may be shipped.
+	Allow Decision = iota
+	// Review means the license may be shipped but a human should look at it.
+	Review
+	// Deny means the license must not be shipped.
+	Deny
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Allow:
+		return "allow"
+	case Review:
+		return "review"
+	case Deny:
+		return "deny"
+	}
+	return fmt.Sprintf("Decision(%d)", int(d))
+}
+
+// ParseDecision parses a decision as written in a policy file: allow,
+// review or deny. Since review findings are reported as warnings and denied
+// ones fail the check, warn and fail are accepted as synonyms.
+func ParseDecision(s string) (Decision, error) {
+	switch s {
+	case "allow":
+		return Allow, nil
+	case "review", "warn":
+		return Review, nil
+	case "deny", "fail":
+		return Deny, nil
+	}
+	return Deny, fmt.Errorf("invalid decision %q, expected allow, review or deny", s)
+}
+
+// NoLicense is the license reported for modules in which no license file
+// was found, following SPDX's NONE.
+const NoLicense = "NONE"
+
+// NoAssertion is the license reported for license files that could not be
+// classified, following SPDX's NOASSERTION.
+const NoAssertion = "NOASSERTION"
+
+// Policy declares which SPDX license identifiers a project accepts.
+// Licenses that appear in none of the lists are denied.
+type Policy struct {
+	Allow      []string    `yaml:"allow"`
+	Deny       []string    `yaml:"deny"`
+	Review     []string    `yaml:"review"`
+	Exceptions []Exception `yaml:"exceptions"`
+	// Unlicensed is the decision (allow, review or deny) for modules without
+	// a license file, or with only NOTICE and COPYRIGHT files. Defaults to
+	// deny.
+	Unlicensed string `yaml:"unlicensed"`
+	// Unclassified is the decision (allow, review or deny) for license files
+	// that don't match any known license. Defaults to review.
+	Unclassified string `yaml:"unclassified"`
+	// MinConfidence is the classifier confidence, from 0 to 1, below which
+	// a license match needs review. Defaults to DefaultMinConfidence.
+	MinConfidence float64 `yaml:"minConfidence"`
+	// MinCoverage is the fraction of a license file, from 0 to 1, that must
+	// match below which the file needs review. Defaults to DefaultMinCoverage.
+	MinCoverage float64 `yaml:"minCoverage"`
+}
+
+// Default thresholds for Policy.MinConfidence and Policy.MinCoverage. Stock
+// license files match with a confidence above 0.95, and cover all of the file
+// but for a title.
+const (
+	DefaultMinConfidence = 0.9
+	DefaultMinCoverage   = 0.95
+)
+
+// exceptionDateLayout is the format of Exception.Expires.
+const exceptionDateLayout = "2006-01-02"
+
+// Exception waives the policy for a specific module, for example because
+// legal has approved its use despite a denied license.
+type Exception struct {
+	// Module is the module path the exception applies to.
+	Module string `yaml:"module"`
+	// Versions optionally restricts the exception to a range of versions,
+	// e.g. "v1.4.2" or ">=v1.2.0, <v2.0.0". Empty matches every version.
+	Versions string `yaml:"versions"`
+	// Licenses optionally restricts the exception to specific SPDX
+	// identifiers. Empty matches every license.
+	Licenses []string `yaml:"licenses"`
+	// Reason records why the exception was granted. It is required.
+	Reason string `yaml:"reason"`
+	// Expires is an optional date (YYYY-MM-DD) on which the exception stops applying.
+	Expires string `yaml:"expires"`
+}
+
+// Matches reports whether the exception covers the given license of a module,
+// regardless of whether it has expired.
+func (e *Exception) Matches(module Module, spdx string) bool {
+	if e.Module != module.Path {
+		return false
+	}
+	if len(e.Licenses) > 0 && !slices.Contains(e.Licenses, spdx) {
+		return false
+	}
+	constraint, err := parseVersionConstraint(e.Versions)
+	if err != nil {
+		return false
+	}
+	return constraint.matches(module.Version)
+}
+
+// Expired reports whether the exception no longer applies at the given time.
+func (e *Exception) Expired(now time.Time) bool {
+	if e.Expires == "" {
+		return false
+	}
+	expires, err := time.Parse(exceptionDateLayout, e.Expires)
+	if err != nil {
+		// Treat an unparseable date as expired rather than granting a waiver forever
+		return true
+	}
+	return !now.Before(expires)
+}
+
+func (e *Exception) validate() error {
+	if e.Module == "" {
+		return errors.New("exception is missing a module")
+	}
+	if strings.TrimSpace(e.Reason) == "" {
+		return fmt.Errorf("exception for %s is missing a reason", e.Module)
+	}
+	if _, err := parseVersionConstraint(e.Versions); err != nil {
+		return fmt.Errorf("exception for %s: %w", e.Module, err)
+	}
+	if e.Expires != "" {
+		if _, err := time.Parse(exceptionDateLayout, e.Expires); err != nil {
+			return fmt.Errorf("exception for %s: invalid expiry date %q, expected YYYY-MM-DD", e.Module, e.Expires)
+		}
+	}
+	return nil
+}
+
+// Exception returns the first exception that covers the given license of a
+// module, or nil if there is none. The returned exception may have expired.
+func (p *Policy) Exception(module Module, spdx string) *Exception {
+	for i := range p.Exceptions {
+		if p.Exceptions[i].Matches(module, spdx) {
+			return &p.Exceptions[i]
+		}
+	}
+	return nil
+}
+
+// DefaultPolicy returns the policy used when a project has no policy file:
+// every license in AllowedLicenses is allowed and everything else is denied.
+func DefaultPolicy() *Policy {
+	var allow []string
+	for spdx := range AllowedLicenses() {
+		allow = append(allow, spdx)
+	}
+	sort.Strings(allow)
+	return &Policy{Allow: allow}
+}
+
+// LoadPolicy reads a YAML policy file from path.
+func LoadPolicy(path string) (*Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy file: %w", err)
+	}
+	policy, err := ParsePolicy(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing policy file %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// ParsePolicy parses a YAML policy document.
+func ParsePolicy(content []byte) (*Policy, error) {
+	var p Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	// An empty document is a valid (if unhelpful) policy that denies everything
+	if err := decoder.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// validate checks the policy for mistakes, such as a license listed under
+// more than one decision.
+func (p *Policy) validate() error {
+	seen := make(map[string]string)
+	lists := []struct {
+		name string
+		ids  []string
+	}{
+		{"allow", p.Allow},
+		{"deny", p.Deny},
+		{"review", p.Review},
+	}
+	for _, list := range lists {
+		for _, id := range list.ids {
+			if id == "" {
+				return fmt.Errorf("empty license identifier in %s list", list.name)
+			}
+			if prev, ok := seen[id]; ok && prev != list.name {
+				return fmt.Errorf("license %s is listed under both %s and %s", id, prev, list.name)
+			}
+			seen[id] = list.name
+		}
+	}
+	for i := range p.Exceptions {
+		if err := p.Exceptions[i].validate(); err != nil {
+			return err
+		}
+	}
+	if p.Unlicensed != "" {
+		if _, err := ParseDecision(p.Unlicensed); err != nil {
+			return fmt.Errorf("unlicensed: %w", err)
+		}
+	}
+	if p.Unclassified != "" {
+		if _, err := ParseDecision(p.Unclassified); err != nil {
+			return fmt.Errorf("unclassified: %w", err)
+		}
+	}
+	if p.MinConfidence < 0 || p.MinConfidence > 1 {
+		return fmt.Errorf("minConfidence must be between 0 and 1, got %v", p.MinConfidence)
+	}
+	if p.MinCoverage < 0 || p.MinCoverage > 1 {
+		return fmt.Errorf("minCoverage must be between 0 and 1, got %v", p.MinCoverage)
+	}
+	return nil
+}
+
+// decisionOr parses a decision from the policy, falling back to def when it
+// isn't set. Decisions are validated when the policy is parsed, so anything
+// invalid is denied.
+func decisionOr(s string, def Decision) Decision {
+	if s == "" {
+		return def
+	}
+	d, _ := ParseDecision(s)
+	return d
+}
+
+// Evaluate returns the decision for a single SPDX identifier.
+func (p *Policy) Evaluate(spdx string) Decision {
+	switch {
+	case slices.Contains(p.Deny, spdx):
+		return Deny
+	case slices.Contains(p.Review, spdx):
+		return Review
+	case slices.Contains(p.Allow, spdx):
+		return Allow
+	}
+	// An exception only grants additional permissions, so unless the policy
+	// lists the combination, a license with an exception is treated like the
+	// license itself
+	if license, _, ok := strings.Cut(spdx, " "+OpWith+" "); ok {
+		return p.Evaluate(license)
+	}
+	return Deny
+}
+
+// EvaluateExpression returns the decision for an SPDX license expression,
+// along with the branch it elected. An OR expression is allowed if any of its
+// branches is, and an AND expression only if all of its operands are.
+func (p *Policy) EvaluateExpression(expr *Expression) (Decision, *Expression) {
+	return evaluateExpression(expr, p.Evaluate)
+}
+
+// PolicyViolation describes a license that the policy denies or flags for review.
+type PolicyViolation struct {
+	Module  string // Module path
+	Version string // Module version
+	License string // SPDX identifier
+	File    string // License file path, relative to the module root
+	Reason  string // Why the license was flagged
+	// Excerpt is the start of the license file's unrecognized text, for
+	// license files that need a human to read them.
+	Excerpt string
+}
+
+func (v PolicyViolation) String() string {
+	if v.File == "" {
+		return fmt.Sprintf("%s@%s: %s: %s", v.Module, v.Version, v.License, v.Reason)
+	}
+	return fmt.Sprintf("%s@%s: %s (%s): %s", v.Module, v.Version, v.License, v.File, v.Reason)
+}
+
+// ErrPolicyViolation is matched by errors.Is for any PolicyError.
+var ErrPolicyViolation = errors.New("license policy violation")
+
+// PolicyError is returned by Run when dependencies violate the license policy.
+type PolicyError struct {
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	entries := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		entries[i] = v.String()
+	}
+	return fmt.Sprintf("found %d dependencies with disallowed licenses:\n  %s", len(e.Violations), strings.Join(entries, "\n  "))
+}
+
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrPolicyViolation
+}
+
+// Check evaluates every license in licenseFiles against the policy. Licenses
+// waived by an unexpired exception are allowed and have their Waiver set.
+// License files that could not be classified are evaluated against the
+// Unclassified decision as NoAssertion. License files whose text matches a
+// license their SPDX-License-Identifier tag doesn't declare need review.
+// Allowed licenses that matched with low confidence, or only matched part of
+// their file, need review since the license may have been modified. It returns
+// the denied licenses and the licenses needing review.
+func (p *Policy) Check(licenseFiles []LicenseFile, now time.Time) (violations, review []PolicyViolation) {
+	unclassified := decisionOr(p.Unclassified, Review)
+	minConfidence := p.MinConfidence
+	if minConfidence == 0 {
+		minConfidence = DefaultMinConfidence
+	}
+	minCoverage := p.MinCoverage
+	if minCoverage == 0 {
+		minCoverage = DefaultMinCoverage
+	}
+	for i := range licenseFiles {
+		lf := &licenseFiles[i]
+		if len(lf.Licenses) == 0 && !lf.IsNotice() && unclassified != Allow {
+			v := PolicyViolation{
+				Module:  lf.Module.Path,
+				Version: lf.Module.Version,
+				License: NoAssertion,
+				File:    lf.RelPath,
+				Reason:  "license could not be classified",
+				Excerpt: excerpt(lf.Path),
+			}
+			if exception := p.Exception(lf.Module, NoAssertion); exception != nil {
+				if !exception.Expired(now) {
+					continue
+				}
+				v.Reason = fmt.Sprintf("exception expired on %s", exception.Expires)
+			}
+			switch unclassified {
+			case Deny:
+				violations = append(violations, v)
+			case Review:
+				review = append(review, v)
+			}
+			continue
+		}
+
+		// Of an expression offering a choice of licenses, only the elected
+		// branch needs to satisfy the policy. Waived licenses can be elected.
+		lf.Elected = nil
+		if expr := lf.Expression(); expr != nil && expr.hasChoice() {
+			_, lf.Elected = evaluateExpression(expr, func(spdx string) Decision {
+				if exception := p.Exception(lf.Module, spdx); exception != nil && !exception.Expired(now) {
+					return Allow
+				}
+				return p.Evaluate(spdx)
+			})
+		}
+
+		flagged := false
+		for j := range lf.Licenses {
+			l := &lf.Licenses[j]
+			if l.Name == "" {
+				continue
+			}
+			if lf.Elected != nil && !slices.Contains(lf.Elected.Licenses(), l.Name) {
+				continue
+			}
+			v := PolicyViolation{
+				Module:  lf.Module.Path,
+				Version: lf.Module.Version,
+				License: l.Name,
+				File:    lf.RelPath,
+				Reason:  p.reason(l.Name),
+			}
+
+			decision := p.Evaluate(l.Name)
+			if decision == Allow {
+				if l.Confidence > 0 && l.Confidence < minConfidence {
+					v.Reason = fmt.Sprintf("low confidence match (%.0f%%)", l.Confidence*100)
+					review = append(review, v)
+					flagged = true
+				}
+				continue
+			}
+
+			flagged = true
+			if exception := p.Exception(lf.Module, l.Name); exception != nil {
+				if !exception.Expired(now) {
+					l.Waiver = exception
+					continue
+				}
+				v.Reason = fmt.Sprintf("exception expired on %s", exception.Expires)
+			}
+
+			switch decision {
+			case Deny:
+				violations = append(violations, v)
+			case Review:
+				review = append(review, v)
+			}
+		}
+
+		if declared, undeclared := undeclaredLicenses(lf.Licenses); len(undeclared) > 0 {
+			review = append(review, PolicyViolation{
+				Module:  lf.Module.Path,
+				Version: lf.Module.Version,
+				License: licenseExpression(undeclared),
+				File:    lf.RelPath,
+				Reason:  fmt.Sprintf("license text doesn't match its SPDX-License-Identifier (%s)", declared),
+			})
+			flagged = true
+		}
+
+		if !flagged && lf.Coverage > 0 && lf.Coverage < minCoverage {
+			review = append(review, PolicyViolation{
+				Module:  lf.Module.Path,
+				Version: lf.Module.Version,
+				License: licenseExpression(lf.Licenses),
+				File:    lf.RelPath,
+				Reason:  fmt.Sprintf("license text only partially matches (%.0f%% of the file)", lf.Coverage*100),
+				Excerpt: unmatchedExcerpt(lf),
+			})
+		}
+	}
+	return violations, review
+}
+
+// licenseExpression joins the SPDX identifiers of licenses with AND.
+func licenseExpression(licenses []License) string {
+	names := make([]string, len(licenses))
+	for i, l := range licenses {
+		names[i] = l.Name
+	}
+	return strings.Join(names, " AND ")
+}
+
+// undeclaredLicenses returns the expression declared by the file's
+// SPDX-License-Identifier tag and the licenses the classifier detected besides
+// it. Both are empty unless the file has a tag.
+func undeclaredLicenses(licenses []License) (declared string, undeclared []License) {
+	for _, l := range licenses {
+		if l.Declared != "" {
+			declared = l.Declared
+		}
+	}
+	if declared == "" {
+		return "", nil
+	}
+	for _, l := range licenses {
+		if l.Declared == "" {
+			undeclared = append(undeclared, l)
+		}
+	}
+	return declared, undeclared
+}
+
+// unmatchedExcerpt returns the first few lines of a license file that aren't
+// part of any license match, or an empty string if it can't be read.
+func unmatchedExcerpt(lf *LicenseFile) string {
+	content, err := os.ReadFile(lf.Path)
+	if err != nil {
+		return ""
+	}
+	lines := unmatchedLines(content, lf.Licenses)
+	if len(lines) > excerptLines {
+		lines = lines[:excerptLines]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckUnlicensed evaluates modules without a license, as listed in
+// Aggregation.Unlicensed, against the policy's Unlicensed decision. Such modules can be waived by an
+// unexpired exception covering the NoLicense identifier. It returns the denied
+// modules and the modules needing review.
+func (p *Policy) CheckUnlicensed(modules []Module, now time.Time) (violations, review []PolicyViolation) {
+	decision := decisionOr(p.Unlicensed, Deny)
+	if decision == Allow {
+		return nil, nil
+	}
+
+	for _, mod := range modules {
+		v := PolicyViolation{
+			Module:  mod.Path,
+			Version: mod.Version,
+			License: NoLicense,
+			Reason:  "no license file found",
+		}
+		if mod.Dir == "" {
+			v.Reason = "module source is not available"
+		}
+		if exception := p.Exception(mod, NoLicense); exception != nil {
+			if !exception.Expired(now) {
+				continue
+			}
+			v.Reason = fmt.Sprintf("exception expired on %s", exception.Expires)
+		}
+
+		switch decision {
+		case Deny:
+			violations = append(violations, v)
+		case Review:
+			review = append(review, v)
+		}
+	}
+	return violations, review
+}
+
+// excerptLines is the number of non-blank lines in an excerpt.
+const excerptLines = 5
+
+// excerpt returns the first few non-blank lines of the file at path, or an
+// empty string if it can't be read.
+func excerpt(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) == excerptLines {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reason explains why a license was not simply allowed.
+func (p *Policy) reason(spdx string) string {
+	switch p.Evaluate(spdx) {
+	case Review:
+		return "license requires review"
+	case Deny:
+		if slices.Contains(p.Deny, spdx) {
+			return "license is denied by policy"
+		}
+		return "license is not in the allow list"
+	}
+	return ""
+}
+
+// versionConstraint is a conjunction of version comparisons such as
+// ">=v1.2.0, <v2.0.0". A bare version is an exact match.
+type versionConstraint []versionComparison
+
+type versionComparison struct {
+	op      string
+	version string
+}
+
+var versionOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+func parseVersionConstraint(s string) (versionConstraint, error) {
+	var constraint versionConstraint
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		op := "="
+		for _, candidate := range versionOperators {
+			if strings.HasPrefix(part, candidate) {
+				op = candidate
+				part = strings.TrimSpace(strings.TrimPrefix(part, candidate))
+				break
+			}
+		}
+		if !semver.IsValid(part) {
+			return nil, fmt.Errorf("invalid version %q in constraint %q", part, s)
+		}
+		constraint = append(constraint, versionComparison{op: op, version: part})
+	}
+	return constraint, nil
+}
+
+func (c versionConstraint) matches(version string) bool {
+	for _, cmp := range c {
+		result := semver.Compare(version, cmp.version)
+		var ok bool
+		switch cmp.op {
+		case "=":
+			ok = result == 0
+		case "!=":
+			ok = result != 0
+		case ">":
+			ok = result > 0
+		case ">=":
+			ok = result >= 0
+		case "<":
+			ok = result < 0
+		case "<=":
+			ok = result <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,642 @@
+package licenseplease
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	t.Parallel()
+
+	content := []byte(`
+allow:
+  - MIT
+  - Apache-2.0
+deny:
+  - GPL-3.0
+review:
+  - MPL-2.0
+`)
+
+	policy, err := ParsePolicy(content)
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		spdx string
+		want Decision
+	}{
+		{"MIT", Allow},
+		{"Apache-2.0", Allow},
+		{"GPL-3.0", Deny},
+		{"MPL-2.0", Review},
+		{"BSD-3-Clause", Deny}, // Not listed anywhere
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spdx, func(t *testing.T) {
+			t.Parallel()
+			if got := policy.Evaluate(tt.spdx); got != tt.want {
+				t.Errorf("Evaluate(%q) = %v, want %v", tt.spdx, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"ConflictingLists", "allow: [MIT]\ndeny: [MIT]\n"},
+		{"UnknownField", "allowed: [MIT]\n"},
+		{"EmptyIdentifier", "allow: ['']\n"},
+		{"NotYAML", "allow: [MIT\n"},
+		{"InvalidUnlicensed", "unlicensed: maybe\n"},
+		{"InvalidUnclassified", "unclassified: ignore\n"},
+		{"InvalidMinConfidence", "minConfidence: 95\n"},
+		{"InvalidMinCoverage", "minCoverage: -1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := ParsePolicy([]byte(tt.content)); err == nil {
+				t.Errorf("ParsePolicy(%q) expected error", tt.content)
+			}
+		})
+	}
+}
+
+func TestParsePolicy_Empty(t *testing.T) {
+	t.Parallel()
+
+	policy, err := ParsePolicy(nil)
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	if got := policy.Evaluate("MIT"); got != Deny {
+		t.Errorf("empty policy Evaluate(MIT) = %v, want %v", got, Deny)
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := DefaultPolicy()
+	for spdx := range knownLicenses {
+		if got := policy.Evaluate(spdx); got != Allow {
+			t.Errorf("DefaultPolicy().Evaluate(%q) = %v, want %v", spdx, got, Allow)
+		}
+	}
+	if got := policy.Evaluate("GPL-3.0"); got != Deny {
+		t.Errorf("DefaultPolicy().Evaluate(GPL-3.0) = %v, want %v", got, Deny)
+	}
+}
+
+func TestOptions_ResolvePolicy(t *testing.T) {
+	t.Parallel()
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, DefaultPolicyFile), []byte("allow: [MIT]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	explicitFile := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(explicitFile, []byte("allow: [ISC]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		projectDir string
+		opts       []Option
+		allowed    string
+	}{
+		{"ProjectFile", projectDir, nil, "MIT"},
+		{"ExplicitFile", projectDir, []Option{WithPolicyFile(explicitFile)}, "ISC"},
+		{"ExplicitPolicy", projectDir, []Option{WithPolicy(&Policy{Allow: []string{"0BSD"}})}, "0BSD"},
+		{"Default", t.TempDir(), nil, "Apache-2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var o options
+			for _, opt := range tt.opts {
+				opt(&o)
+			}
+			policy, err := o.resolvePolicy(tt.projectDir)
+			if err != nil {
+				t.Fatalf("resolvePolicy() error = %v", err)
+			}
+			if got := policy.Evaluate(tt.allowed); got != Allow {
+				t.Errorf("Evaluate(%q) = %v, want %v", tt.allowed, got, Allow)
+			}
+		})
+	}
+}
+
+func TestOptions_ResolvePolicy_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	var o options
+	WithPolicyFile(filepath.Join(t.TempDir(), "missing.yaml"))(&o)
+	if _, err := o.resolvePolicy(t.TempDir()); err == nil {
+		t.Error("expected error for missing policy file")
+	}
+}
+
+func TestParsePolicy_Exceptions(t *testing.T) {
+	t.Parallel()
+
+	content := []byte(`
+allow: [MIT]
+exceptions:
+  - module: github.com/foo/gpl
+    versions: ">=v1.2.0, <v2.0.0"
+    licenses: [GPL-3.0]
+    reason: Approved by legal for internal tooling only
+    expires: 2030-01-01
+`)
+
+	policy, err := ParsePolicy(content)
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	if len(policy.Exceptions) != 1 {
+		t.Fatalf("expected 1 exception, got %d", len(policy.Exceptions))
+	}
+
+	tests := []struct {
+		name   string
+		module Module
+		spdx   string
+		want   bool
+	}{
+		{"InRange", Module{Path: "github.com/foo/gpl", Version: "v1.5.0"}, "GPL-3.0", true},
+		{"LowerBound", Module{Path: "github.com/foo/gpl", Version: "v1.2.0"}, "GPL-3.0", true},
+		{"BelowRange", Module{Path: "github.com/foo/gpl", Version: "v1.1.9"}, "GPL-3.0", false},
+		{"AboveRange", Module{Path: "github.com/foo/gpl", Version: "v2.0.0"}, "GPL-3.0", false},
+		{"OtherLicense", Module{Path: "github.com/foo/gpl", Version: "v1.5.0"}, "AGPL-3.0", false},
+		{"OtherModule", Module{Path: "github.com/foo/other", Version: "v1.5.0"}, "GPL-3.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := policy.Exception(tt.module, tt.spdx) != nil
+			if got != tt.want {
+				t.Errorf("Exception(%s@%s, %s) matched = %v, want %v", tt.module.Path, tt.module.Version, tt.spdx, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePolicy_InvalidExceptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"MissingModule", "exceptions:\n  - reason: because\n"},
+		{"MissingReason", "exceptions:\n  - module: github.com/foo/bar\n"},
+		{"InvalidVersion", "exceptions:\n  - module: github.com/foo/bar\n    reason: because\n    versions: '>= 1.0'\n"},
+		{"InvalidExpiry", "exceptions:\n  - module: github.com/foo/bar\n    reason: because\n    expires: next week\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := ParsePolicy([]byte(tt.content)); err == nil {
+				t.Errorf("ParsePolicy(%q) expected error", tt.content)
+			}
+		})
+	}
+}
+
+func TestException_Matches_AnyVersion(t *testing.T) {
+	t.Parallel()
+
+	e := &Exception{Module: "github.com/foo/bar", Reason: "because"}
+	for _, version := range []string{"v0.0.1", "v1.0.0", "v2.3.4-0.20240101000000-abcdef123456"} {
+		if !e.Matches(Module{Path: "github.com/foo/bar", Version: version}, "GPL-3.0") {
+			t.Errorf("exception without versions should match %s", version)
+		}
+	}
+}
+
+func TestException_Expired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expires string
+		want    bool
+	}{
+		{"", false},
+		{"2025-06-16", false},
+		{"2025-06-15", true},
+		{"2024-01-01", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expires, func(t *testing.T) {
+			t.Parallel()
+			e := &Exception{Module: "github.com/foo/bar", Reason: "because", Expires: tt.expires}
+			if got := e.Expired(now); got != tt.want {
+				t.Errorf("Expired() with expires %q = %v, want %v", tt.expires, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{
+		Allow:  []string{"MIT"},
+		Deny:   []string{"GPL-3.0"},
+		Review: []string{"MPL-2.0"},
+		Exceptions: []Exception{
+			{Module: "github.com/waived/gpl", Reason: "Approved"},
+			{Module: "github.com/expired/gpl", Reason: "Approved", Expires: "2020-01-01"},
+		},
+	}
+
+	licenseFiles := []LicenseFile{
+		{RelPath: "LICENSE", Module: Module{Path: "github.com/ok/mit", Version: "v1.0.0"}, Licenses: []License{{Name: "MIT"}}},
+		{RelPath: "LICENSE", Module: Module{Path: "github.com/bad/gpl", Version: "v1.0.0"}, Licenses: []License{{Name: "GPL-3.0"}}},
+		{RelPath: "LICENSE", Module: Module{Path: "github.com/maybe/mpl", Version: "v1.0.0"}, Licenses: []License{{Name: "MPL-2.0"}}},
+		{RelPath: "LICENSE", Module: Module{Path: "github.com/waived/gpl", Version: "v1.0.0"}, Licenses: []License{{Name: "GPL-3.0"}}},
+		{RelPath: "LICENSE", Module: Module{Path: "github.com/expired/gpl", Version: "v1.0.0"}, Licenses: []License{{Name: "GPL-3.0"}}},
+	}
+
+	violations, review := policy.Check(licenseFiles, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	wantViolations := []PolicyViolation{
+		{Module: "github.com/bad/gpl", Version: "v1.0.0", License: "GPL-3.0", File: "LICENSE", Reason: "license is denied by policy"},
+		{Module: "github.com/expired/gpl", Version: "v1.0.0", License: "GPL-3.0", File: "LICENSE", Reason: "exception expired on 2020-01-01"},
+	}
+	if !slices.Equal(violations, wantViolations) {
+		t.Errorf("violations = %v, want %v", violations, wantViolations)
+	}
+
+	wantReview := []PolicyViolation{
+		{Module: "github.com/maybe/mpl", Version: "v1.0.0", License: "MPL-2.0", File: "LICENSE", Reason: "license requires review"},
+	}
+	if !slices.Equal(review, wantReview) {
+		t.Errorf("review = %v, want %v", review, wantReview)
+	}
+
+	if licenseFiles[3].Licenses[0].Waiver == nil {
+		t.Error("expected waived license to record its exception")
+	}
+	if licenseFiles[4].Licenses[0].Waiver != nil {
+		t.Error("expired exception should not be recorded as a waiver")
+	}
+}
+
+func TestPolicy_Check_UnlistedReason(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{Allow: []string{"MIT"}}
+	licenseFiles := []LicenseFile{
+		{RelPath: "COPYING", Module: Module{Path: "github.com/foo/bar", Version: "v1.0.0"}, Licenses: []License{{Name: "LGPL-2.1"}}},
+	}
+
+	violations, _ := policy.Check(licenseFiles, time.Now())
+	if len(violations) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(violations))
+	}
+	if got, want := violations[0].String(), "github.com/foo/bar@v1.0.0: LGPL-2.1 (COPYING): license is not in the allow list"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestPolicy_CheckUnlicensed(t *testing.T) {
+	t.Parallel()
+
+	modules := []Module{
+		{Path: "github.com/no/license", Version: "v1.0.0", Dir: "/tmp/mod/no/license"},
+		{Path: "github.com/no/source", Version: "v1.0.0"},
+		{Path: "github.com/waived/none", Version: "v1.0.0", Dir: "/tmp/mod/waived/none"},
+	}
+	exceptions := []Exception{
+		{Module: "github.com/waived/none", Licenses: []string{NoLicense}, Reason: "Written permission from the author"},
+	}
+	unlicensed := []PolicyViolation{
+		{Module: "github.com/no/license", Version: "v1.0.0", License: NoLicense, Reason: "no license file found"},
+		{Module: "github.com/no/source", Version: "v1.0.0", License: NoLicense, Reason: "module source is not available"},
+	}
+
+	tests := []struct {
+		name           string
+		unlicensed     string
+		wantViolations []PolicyViolation
+		wantReview     []PolicyViolation
+	}{
+		{"DefaultDeny", "", unlicensed, nil},
+		{"Deny", "deny", unlicensed, nil},
+		{"Review", "review", nil, unlicensed},
+		{"Allow", "allow", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			policy := &Policy{Unlicensed: tt.unlicensed, Exceptions: exceptions}
+			violations, review := policy.CheckUnlicensed(modules, time.Now())
+			if !slices.Equal(violations, tt.wantViolations) {
+				t.Errorf("violations = %v, want %v", violations, tt.wantViolations)
+			}
+			if !slices.Equal(review, tt.wantReview) {
+				t.Errorf("review = %v, want %v", review, tt.wantReview)
+			}
+		})
+	}
+}
+
+func TestPolicy_Check_Unclassified(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	licensePath := filepath.Join(dir, "LICENSE")
+	content := "Custom License\n\nYou may use this software\nonly on Tuesdays.\n\n\nline 4\nline 5\nline 6\n"
+	if err := os.WriteFile(licensePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := Module{Path: "github.com/custom/license", Version: "v1.0.0", Dir: dir}
+	finding := PolicyViolation{
+		Module:  "github.com/custom/license",
+		Version: "v1.0.0",
+		License: NoAssertion,
+		File:    "LICENSE",
+		Reason:  "license could not be classified",
+		Excerpt: "Custom License\nYou may use this software\nonly on Tuesdays.\nline 4\nline 5",
+	}
+
+	tests := []struct {
+		name           string
+		unclassified   string
+		wantViolations []PolicyViolation
+		wantReview     []PolicyViolation
+	}{
+		{"DefaultReview", "", nil, []PolicyViolation{finding}},
+		{"Warn", "warn", nil, []PolicyViolation{finding}},
+		{"Fail", "fail", []PolicyViolation{finding}, nil},
+		{"Allow", "allow", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			policy := &Policy{Unclassified: tt.unclassified}
+			licenseFiles := []LicenseFile{
+				{Path: licensePath, RelPath: "LICENSE", Module: module},
+				// NOTICE files aren't licenses, so they are never unclassified
+				{Path: filepath.Join(dir, "NOTICE"), RelPath: "NOTICE", Module: module},
+			}
+			violations, review := policy.Check(licenseFiles, time.Now())
+			if !slices.Equal(violations, tt.wantViolations) {
+				t.Errorf("violations = %v, want %v", violations, tt.wantViolations)
+			}
+			if !slices.Equal(review, tt.wantReview) {
+				t.Errorf("review = %v, want %v", review, tt.wantReview)
+			}
+		})
+	}
+}
+
+func TestPolicy_Check_MatchQuality(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	licensePath := filepath.Join(dir, "LICENSE")
+	content := "MIT License text\nNo use by anyone named Bob.\n"
+	if err := os.WriteFile(licensePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	module := Module{Path: "github.com/foo/bar", Version: "v1.0.0", Dir: dir}
+	tests := []struct {
+		name        string
+		policy      *Policy
+		license     License
+		coverage    float64
+		wantReason  string
+		wantExcerpt string
+	}{
+		{"Exact", &Policy{Allow: []string{"MIT"}}, License{Name: "MIT", Confidence: 1, StartLine: 1, EndLine: 1}, 1, "", ""},
+		{"LowConfidence", &Policy{Allow: []string{"MIT"}}, License{Name: "MIT", Confidence: 0.85, StartLine: 1, EndLine: 1}, 1, "low confidence match (85%)", ""},
+		{"CustomConfidence", &Policy{Allow: []string{"MIT"}, MinConfidence: 0.8}, License{Name: "MIT", Confidence: 0.85, StartLine: 1, EndLine: 1}, 1, "", ""},
+		{"UnknownConfidence", &Policy{Allow: []string{"MIT"}}, License{Name: "MIT"}, 0, "", ""},
+		{"Partial", &Policy{Allow: []string{"MIT"}}, License{Name: "MIT", Confidence: 1, StartLine: 1, EndLine: 1}, 0.5, "license text only partially matches (50% of the file)", "No use by anyone named Bob."},
+		{"CustomCoverage", &Policy{Allow: []string{"MIT"}, MinCoverage: 0.4}, License{Name: "MIT", Confidence: 1, StartLine: 1, EndLine: 1}, 0.5, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			licenseFiles := []LicenseFile{
+				{Path: licensePath, RelPath: "LICENSE", Module: module, Licenses: []License{tt.license}, Coverage: tt.coverage},
+			}
+			violations, review := tt.policy.Check(licenseFiles, time.Now())
+			if len(violations) != 0 {
+				t.Errorf("unexpected violations: %v", violations)
+			}
+			if tt.wantReason == "" {
+				if len(review) != 0 {
+					t.Errorf("unexpected review: %v", review)
+				}
+				return
+			}
+			if len(review) != 1 {
+				t.Fatalf("review = %v, want 1 finding", review)
+			}
+			if review[0].Reason != tt.wantReason || review[0].Excerpt != tt.wantExcerpt {
+				t.Errorf("review = %+v, want reason %q and excerpt %q", review[0], tt.wantReason, tt.wantExcerpt)
+			}
+		})
+	}
+}
+
+func TestPolicy_Check_DeclaredMismatch(t *testing.T) {
+	t.Parallel()
+
+	policy := &Policy{Allow: []string{"MIT", "Apache-2.0"}}
+	module := Module{Path: "github.com/foo/bar", Version: "v1.0.0"}
+	declared := License{Name: "Apache-2.0", Confidence: 1, StartLine: 1, EndLine: 1, Declared: "Apache-2.0"}
+	detected := License{Name: "MIT", Confidence: 1, StartLine: 3, EndLine: 21}
+
+	licenseFiles := []LicenseFile{
+		{RelPath: "LICENSE", Module: module, Licenses: []License{declared}},
+		{RelPath: "COPYING", Module: module, Licenses: []License{declared, detected}},
+	}
+	violations, review := policy.Check(licenseFiles, time.Now())
+	if len(violations) != 0 {
+		t.Errorf("unexpected violations: %v", violations)
+	}
+	if len(review) != 1 {
+		t.Fatalf("review = %v, want 1 finding", review)
+	}
+	want := PolicyViolation{
+		Module:  "github.com/foo/bar",
+		Version: "v1.0.0",
+		License: "MIT",
+		File:    "COPYING",
+		Reason:  "license text doesn't match its SPDX-License-Identifier (Apache-2.0)",
+	}
+	if review[0] != want {
+		t.Errorf("review = %+v, want %+v", review[0], want)
+	}
+}
+
+func TestPolicy_Check_Expression(t *testing.T) {
+	t.Parallel()
+
+	module := Module{Path: "github.com/foo/dual", Version: "v1.0.0"}
+	declared := func(expr string, names ...string) []License {
+		var licenses []License
+		for _, name := range names {
+			licenses = append(licenses, License{Name: name, Confidence: 1, Declared: expr})
+		}
+		return licenses
+	}
+	tests := []struct {
+		name           string
+		policy         *Policy
+		licenses       []License
+		wantElected    string
+		wantViolations []string
+	}{
+		{
+			name:        "FirstAllowedBranch",
+			policy:      &Policy{Allow: []string{"MIT", "Apache-2.0"}},
+			licenses:    declared("MIT OR Apache-2.0", "MIT", "Apache-2.0"),
+			wantElected: "MIT",
+		},
+		{
+			name:        "OnlyAllowedBranch",
+			policy:      &Policy{Allow: []string{"Apache-2.0"}},
+			licenses:    declared("GPL-3.0-only OR Apache-2.0", "GPL-3.0-only", "Apache-2.0"),
+			wantElected: "Apache-2.0",
+		},
+		{
+			name: "WaivedBranch",
+			policy: &Policy{Exceptions: []Exception{
+				{Module: "github.com/foo/dual", Licenses: []string{"Apache-2.0"}, Reason: "Approved by legal"},
+			}},
+			licenses:    declared("GPL-3.0-only OR Apache-2.0", "GPL-3.0-only", "Apache-2.0"),
+			wantElected: "Apache-2.0",
+		},
+		{
+			name:           "NoAllowedBranch",
+			policy:         &Policy{Allow: []string{"MIT"}},
+			licenses:       declared("GPL-3.0-only OR AGPL-3.0-only", "GPL-3.0-only", "AGPL-3.0-only"),
+			wantElected:    "GPL-3.0-only",
+			wantViolations: []string{"GPL-3.0-only"},
+		},
+		{
+			name:           "Conjunction",
+			policy:         &Policy{Allow: []string{"MIT", "Apache-2.0"}},
+			licenses:       []License{{Name: "MIT"}, {Name: "GPL-3.0-only"}},
+			wantViolations: []string{"GPL-3.0-only"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			licenseFiles := []LicenseFile{{RelPath: "LICENSE", Module: module, Licenses: tt.licenses}}
+			violations, _ := tt.policy.Check(licenseFiles, time.Now())
+
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.License)
+			}
+			if !slices.Equal(got, tt.wantViolations) {
+				t.Errorf("violations = %v, want %v", got, tt.wantViolations)
+			}
+			var elected string
+			if licenseFiles[0].Elected != nil {
+				elected = licenseFiles[0].Elected.String()
+			}
+			if elected != tt.wantElected {
+				t.Errorf("Elected = %q, want %q", elected, tt.wantElected)
+			}
+		})
+	}
+}
+
+func TestParseDecision(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s    string
+		want Decision
+	}{
+		{"allow", Allow},
+		{"review", Review},
+		{"warn", Review},
+		{"deny", Deny},
+		{"fail", Deny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseDecision(tt.s)
+			if err != nil {
+				t.Fatalf("ParseDecision(%q) error = %v", tt.s, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDecision(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseDecision("ignore"); err == nil {
+		t.Error("expected error for an unknown decision")
+	}
+}
+
+func TestPolicyViolation_String_NoFile(t *testing.T) {
+	t.Parallel()
+
+	v := PolicyViolation{Module: "github.com/foo/bar", Version: "v1.0.0", License: NoLicense, Reason: "no license file found"}
+	if got, want := v.String(), "github.com/foo/bar@v1.0.0: NONE: no license file found"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestPolicyError(t *testing.T) {
+	t.Parallel()
+
+	var err error = &PolicyError{Violations: []PolicyViolation{
+		{Module: "github.com/foo/bar", Version: "v1.0.0", License: "GPL-3.0", File: "LICENSE", Reason: "license is denied by policy"},
+	}}
+
+	if !errors.Is(err, ErrPolicyViolation) {
+		t.Error("PolicyError should match ErrPolicyViolation")
+	}
+
+	wrapped := fmt.Errorf("release check: %w", err)
+	var policyErr *PolicyError
+	if !errors.As(wrapped, &policyErr) {
+		t.Fatal("errors.As should find the PolicyError")
+	}
+	if len(policyErr.Violations) != 1 || policyErr.Violations[0].Module != "github.com/foo/bar" {
+		t.Errorf("unexpected violations: %v", policyErr.Violations)
+	}
+}
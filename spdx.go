@@ -0,0 +1,68 @@
+package licenseplease
+
+import "strings"
+
+// spdxLicenseList holds the identifiers on the SPDX License List, lower cased
+// since SPDX identifiers are matched case-insensitively. It covers every
+// listed license the classifier can detect, including deprecated identifiers
+// such as GPL-3.0, and those commonly found in SPDX-License-Identifier tags.
+var spdxLicenseList = toSet(
+	"0BSD", "AFL-1.1", "AFL-1.2", "AFL-2.0", "AFL-2.1", "AFL-3.0",
+	"AGPL-1.0", "AGPL-1.0-only", "AGPL-1.0-or-later", "AGPL-3.0", "AGPL-3.0-only", "AGPL-3.0-or-later",
+	"AML", "AMPAS", "APSL-1.0", "APSL-1.1", "APSL-1.2", "APSL-2.0",
+	"Apache-1.0", "Apache-1.1", "Apache-2.0",
+	"Artistic-1.0", "Artistic-1.0-Perl", "Artistic-1.0-cl8", "Artistic-2.0",
+	"BSD-1-Clause", "BSD-2-Clause", "BSD-2-Clause-FreeBSD", "BSD-2-Clause-NetBSD", "BSD-2-Clause-Patent", "BSD-2-Clause-Views",
+	"BSD-3-Clause", "BSD-3-Clause-Attribution", "BSD-3-Clause-Clear", "BSD-3-Clause-LBNL",
+	"BSD-4-Clause", "BSD-4-Clause-UC", "BSD-Protection", "BSD-Source-Code", "BSL-1.0", "BUSL-1.1",
+	"Beerware", "BitTorrent-1.1", "blessing", "bzip2-1.0.6",
+	"CAL-1.0",
+	"CC-BY-1.0", "CC-BY-2.0", "CC-BY-2.5", "CC-BY-3.0", "CC-BY-4.0",
+	"CC-BY-NC-1.0", "CC-BY-NC-2.0", "CC-BY-NC-2.5", "CC-BY-NC-3.0", "CC-BY-NC-4.0",
+	"CC-BY-NC-ND-1.0", "CC-BY-NC-ND-2.0", "CC-BY-NC-ND-2.5", "CC-BY-NC-ND-3.0", "CC-BY-NC-ND-4.0",
+	"CC-BY-NC-SA-1.0", "CC-BY-NC-SA-2.0", "CC-BY-NC-SA-2.5", "CC-BY-NC-SA-3.0", "CC-BY-NC-SA-4.0",
+	"CC-BY-ND-1.0", "CC-BY-ND-2.0", "CC-BY-ND-2.5", "CC-BY-ND-3.0", "CC-BY-ND-4.0",
+	"CC-BY-SA-1.0", "CC-BY-SA-2.0", "CC-BY-SA-2.5", "CC-BY-SA-3.0", "CC-BY-SA-4.0",
+	"CC0-1.0", "CDDL-1.0", "CDDL-1.1", "CDLA-Permissive-1.0", "CDLA-Permissive-2.0", "CECILL-2.1",
+	"CNRI-Python-GPL-Compatible", "CPAL-1.0", "CPL-1.0", "curl",
+	"DRL-1.0", "ECL-2.0", "EFL-2.0", "EPL-1.0", "EPL-2.0", "EUPL-1.0", "EUPL-1.1", "EUPL-1.2", "eGenix", "Elastic-2.0",
+	"FTL", "FreeImage",
+	"GPL-1.0", "GPL-1.0-only", "GPL-1.0-or-later", "GPL-2.0", "GPL-2.0-only", "GPL-2.0-or-later",
+	"GPL-2.0-with-GCC-exception", "GPL-2.0-with-autoconf-exception", "GPL-2.0-with-bison-exception",
+	"GPL-2.0-with-classpath-exception", "GPL-2.0-with-font-exception",
+	"GPL-3.0", "GPL-3.0-only", "GPL-3.0-or-later", "GPL-3.0-with-GCC-exception", "GPL-3.0-with-autoconf-exception",
+	"HPND", "HPND-sell-variant",
+	"ICU", "IJG", "IPL-1.0", "ISC", "ImageMagick", "Info-ZIP",
+	"JSON",
+	"LGPL-2.0", "LGPL-2.0-only", "LGPL-2.0-or-later", "LGPL-2.1", "LGPL-2.1-only", "LGPL-2.1-or-later",
+	"LGPL-3.0", "LGPL-3.0-only", "LGPL-3.0-or-later", "LGPLLR",
+	"LPL-1.0", "LPL-1.02", "LPPL-1.3c", "Libpng", "libpng-2.0", "libtiff", "Linux-OpenIB",
+	"MIT", "MIT-0", "MIT-Modern-Variant", "MPL-1.0", "MPL-1.1", "MPL-2.0", "MPL-2.0-no-copyleft-exception",
+	"MS-PL", "MS-RL", "MulanPSL-2.0",
+	"NAIST-2003", "NCSA", "NGPL", "NPL-1.0", "NPL-1.1",
+	"ODbL-1.0", "OFL-1.1", "OSL-1.0", "OSL-1.1", "OSL-2.0", "OSL-2.1", "OSL-3.0", "OpenSSL",
+	"PHP-3.0", "PHP-3.01", "PSF-2.0", "PostgreSQL", "Python-2.0",
+	"QPL-1.0", "Qhull",
+	"Ruby",
+	"SGI-B-1.0", "SGI-B-1.1", "SGI-B-2.0", "SISSL", "SISSL-1.2", "SSPL-1.0", "Sleepycat", "Spencer-86", "SunPro",
+	"UPL-1.0", "Unicode-3.0", "Unicode-DFS-2015", "Unicode-DFS-2016", "Unicode-TOU", "Unlicense",
+	"Vim",
+	"W3C", "W3C-19980720", "W3C-20150513", "WTFPL",
+	"X11", "Xnet",
+	"ZPL-1.1", "ZPL-2.0", "ZPL-2.1", "Zend-2.0", "Zlib", "zlib-acknowledgement",
+)
+
+// IsSPDXLicenseID reports whether id is on the SPDX License List. Other
+// licenses, such as those the classifier knows by a name of its own, can only
+// be referred to in SPDX documents with a LicenseRef.
+func IsSPDXLicenseID(id string) bool {
+	return spdxLicenseList[strings.ToLower(id)]
+}
+
+func toSet(ids ...string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[strings.ToLower(id)] = true
+	}
+	return set
+}
@@ -0,0 +1,31 @@
+package licenseplease
+
+import "testing"
+
+func TestIsSPDXLicenseID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"MIT", true},
+		{"GPL-3.0", true},
+		{"LGPL-2.1-only", true},
+		{"0BSD", true},
+		{"cURL", true},
+		{"Commons-Clause", false},
+		{"Apache-with-LLVM-Exception", false},
+		{"LicenseRef-nacl", false},
+		{"Custom", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			t.Parallel()
+			if got := IsSPDXLicenseID(tt.id); got != tt.want {
+				t.Errorf("IsSPDXLicenseID(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+// Package pflag is a minimal stand-in for a fork of github.com/spf13/pflag.
+package pflag
+
+import "os"
+
+// Parse does nothing; the fork only needs to provide the API used by main.
+func Parse() {}
+
+// Args returns the command line arguments.
+func Args() []string { return os.Args[1:] }
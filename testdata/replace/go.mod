@@ -0,0 +1,12 @@
+module github.com/williammartin/licenseplease/testdata/replace
+
+go 1.21
+
+require (
+	github.com/davecgh/go-spew v1.1.1
+	github.com/spf13/pflag v1.0.5
+)
+
+replace github.com/spf13/pflag => ./fork
+
+replace github.com/davecgh/go-spew => github.com/davecgh/go-spew v1.1.0
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"github.com/davecgh/go-spew/spew"
+	"github.com/spf13/pflag"
+)
+
+func main() {
+	pflag.Parse()
+	spew.Dump(pflag.Args())
+}
@@ -0,0 +1,12 @@
+// Package a is a workspace module for e2e testing of license-please.
+//
+// Expected licenses:
+// - github.com/spf13/pflag: BSD-3-Clause
+package a
+
+import "github.com/spf13/pflag"
+
+var _ = pflag.FlagSet{}
+
+// Placeholder is referenced by module b.
+var Placeholder struct{}
@@ -0,0 +1,5 @@
+module github.com/williammartin/licenseplease/testdata/workspace/a
+
+go 1.21
+
+require github.com/spf13/pflag v1.0.5
@@ -0,0 +1,14 @@
+// Package b is a workspace module for e2e testing of license-please.
+// It imports module a from the workspace, which must not be reported.
+//
+// Expected licenses:
+// - github.com/davecgh/go-spew: ISC
+package b
+
+import (
+	"github.com/davecgh/go-spew/spew"
+	"github.com/williammartin/licenseplease/testdata/workspace/a"
+)
+
+var _ = spew.Dump
+var _ = a.Placeholder
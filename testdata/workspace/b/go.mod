@@ -0,0 +1,5 @@
+module github.com/williammartin/licenseplease/testdata/workspace/b
+
+go 1.21
+
+require github.com/davecgh/go-spew v1.1.1
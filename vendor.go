@@ -0,0 +1,130 @@
+package licenseplease
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// VendorResolver implements ModuleResolver using the project's vendor
+// directory, as described by vendor/modules.txt. It needs neither network
+// access nor a module cache.
+type VendorResolver struct{}
+
+func (r *VendorResolver) Resolve(ctx context.Context, projectDir string) ([]Module, error) {
+	vendorDir := filepath.Join(projectDir, "vendor")
+	f, err := os.Open(filepath.Join(vendorDir, "modules.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("reading vendor manifest: %w", err)
+	}
+	defer f.Close()
+
+	var modules []Module
+	var current *Module
+	hasPackages := false
+	flush := func() {
+		// Modules without any vendored packages contribute nothing to the build
+		if current != nil && hasPackages {
+			modules = append(modules, *current)
+		}
+		current = nil
+		hasPackages = false
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "## "):
+			// Annotations such as "## explicit; go 1.21"
+		case strings.HasPrefix(line, "# "):
+			flush()
+			// "# path version", optionally followed by "=> replacement [version]"
+			fields := strings.Fields(strings.TrimPrefix(line, "# "))
+			if len(fields) == 0 {
+				continue
+			}
+			current = &Module{
+				Path: fields[0],
+				Dir:  filepath.Join(vendorDir, filepath.FromSlash(fields[0])),
+			}
+			if len(fields) > 1 && fields[1] != "=>" {
+				current.Version = fields[1]
+			}
+			// Replaced modules are vendored under their original path
+			if i := slices.Index(fields, "=>"); i >= 0 && i+1 < len(fields) {
+				current.Replace = &Module{Path: fields[i+1], Dir: current.Dir}
+				if i+2 < len(fields) {
+					current.Replace.Version = fields[i+2]
+				}
+			}
+		default:
+			// A vendored package of the current module
+			hasPackages = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading vendor manifest: %w", err)
+	}
+	flush()
+	return modules, nil
+}
+
+// vendorMode reports whether the go command would build the project in
+// projectDir from its vendor directory (-mod=vendor).
+func vendorMode(ctx context.Context, projectDir string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(projectDir, "vendor", "modules.txt")); err != nil {
+		return false, nil
+	}
+	gowork, err := goEnv(ctx, projectDir, "GOWORK")
+	if err != nil {
+		return false, err
+	}
+	if gowork != "" && gowork != "off" {
+		return false, nil
+	}
+	goflags, err := goEnv(ctx, projectDir, "GOFLAGS")
+	if err != nil {
+		return false, err
+	}
+	gomod, err := os.ReadFile(filepath.Join(projectDir, "go.mod"))
+	if err != nil {
+		return false, nil
+	}
+	file, err := modfile.ParseLax("go.mod", gomod, nil)
+	if err != nil {
+		return false, fmt.Errorf("parsing go.mod: %w", err)
+	}
+	goVersion := ""
+	if file.Go != nil {
+		goVersion = file.Go.Version
+	}
+	return vendorEnabled(goflags, goVersion), nil
+}
+
+// vendorEnabled applies the go command's rules for -mod=vendor, given a
+// vendor/modules.txt exists: an explicit -mod flag in GOFLAGS wins, otherwise
+// vendoring is the default for modules declaring go 1.14 or later.
+func vendorEnabled(goflags string, goVersion string) bool {
+	for _, flag := range strings.Fields(goflags) {
+		if mode, ok := strings.CutPrefix(flag, "-mod="); ok {
+			return mode == "vendor"
+		}
+	}
+	// Drop pre-release suffixes such as 1.21rc1, which aren't valid semver
+	if i := strings.IndexFunc(goVersion, func(r rune) bool { return r != '.' && !unicode.IsDigit(r) }); i >= 0 {
+		goVersion = goVersion[:i]
+	}
+	return goVersion != "" && semver.Compare("v"+goVersion, "v1.14") >= 0
+}
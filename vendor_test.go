@@ -0,0 +1,138 @@
+package licenseplease
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVendorResolver_Resolve(t *testing.T) {
+	t.Parallel()
+
+	projectDir := t.TempDir()
+	modulesTxt := `# github.com/foo/bar v1.2.3
+## explicit; go 1.21
+github.com/foo/bar
+github.com/foo/bar/sub
+# github.com/foo/bar/v2 v2.0.0
+## explicit; go 1.21
+github.com/foo/bar/v2
+# github.com/unused/mod v0.1.0
+## explicit; go 1.21
+# github.com/forked/mod v1.0.0 => github.com/me/mod v1.0.1
+## explicit
+github.com/forked/mod
+# github.com/local/mod => ../local
+## explicit
+github.com/local/mod
+`
+	if err := os.MkdirAll(filepath.Join(projectDir, "vendor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "vendor", "modules.txt"), []byte(modulesTxt), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &VendorResolver{}
+	modules, err := resolver.Resolve(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	vendorDir := filepath.Join(projectDir, "vendor")
+	expected := []Module{
+		{Path: "github.com/foo/bar", Version: "v1.2.3", Dir: filepath.Join(vendorDir, "github.com/foo/bar")},
+		{Path: "github.com/foo/bar/v2", Version: "v2.0.0", Dir: filepath.Join(vendorDir, "github.com/foo/bar/v2")},
+		{Path: "github.com/forked/mod", Version: "v1.0.0", Dir: filepath.Join(vendorDir, "github.com/forked/mod"), Replace: &Module{Path: "github.com/me/mod", Version: "v1.0.1"}},
+		{Path: "github.com/local/mod", Version: "", Dir: filepath.Join(vendorDir, "github.com/local/mod"), Replace: &Module{Path: "../local"}},
+	}
+
+	if len(modules) != len(expected) {
+		t.Fatalf("Resolve() returned %d modules, want %d: %+v", len(modules), len(expected), modules)
+	}
+	for i, want := range expected {
+		got := modules[i]
+		if got.Path != want.Path || got.Version != want.Version || got.Dir != want.Dir {
+			t.Errorf("module %d = %+v, want %+v", i, got, want)
+		}
+		if (got.Replace == nil) != (want.Replace == nil) {
+			t.Errorf("module %d replace = %+v, want %+v", i, got.Replace, want.Replace)
+		} else if got.Replace != nil && (got.Replace.Path != want.Replace.Path || got.Replace.Version != want.Replace.Version) {
+			t.Errorf("module %d replace = %+v, want %+v", i, got.Replace, want.Replace)
+		}
+	}
+}
+
+func TestVendorResolver_MissingManifest(t *testing.T) {
+	t.Parallel()
+
+	resolver := &VendorResolver{}
+	if _, err := resolver.Resolve(context.Background(), t.TempDir()); err == nil {
+		t.Error("expected error when vendor/modules.txt is missing")
+	}
+}
+
+func TestVendorEnabled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		goflags   string
+		goVersion string
+		want      bool
+	}{
+		{"DefaultModern", "", "1.21", true},
+		{"DefaultPatch", "", "1.21.5", true},
+		{"DefaultPrerelease", "", "1.23rc1", true},
+		{"DefaultOld", "", "1.13", false},
+		{"NoGoDirective", "", "", false},
+		{"ExplicitVendor", "-mod=vendor", "1.13", true},
+		{"ExplicitMod", "-mod=mod", "1.21", false},
+		{"ExplicitReadonly", "-trimpath -mod=readonly", "1.21", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := vendorEnabled(tt.goflags, tt.goVersion); got != tt.want {
+				t.Errorf("vendorEnabled(%q, %q) = %v, want %v", tt.goflags, tt.goVersion, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregator_Aggregate_NestedModules(t *testing.T) {
+	t.Parallel()
+
+	// Vendored modules can be nested inside each other's directories
+	modules := []Module{
+		{Path: "github.com/foo/bar", Version: "v1.0.0", Dir: "/vendor/github.com/foo/bar"},
+		{Path: "github.com/foo/bar/v2", Version: "v2.0.0", Dir: "/vendor/github.com/foo/bar/v2"},
+	}
+
+	finderPaths := map[string][]string{
+		"github.com/foo/bar":    {"/vendor/github.com/foo/bar/LICENSE", "/vendor/github.com/foo/bar/v2/LICENSE"},
+		"github.com/foo/bar/v2": {"/vendor/github.com/foo/bar/v2/LICENSE"},
+	}
+
+	aggregator := &Aggregator{
+		Resolver:   &mockResolver{modules: modules},
+		Finder:     &mockFinder{paths: finderPaths},
+		Classifier: &mockClassifier{},
+	}
+
+	result, err := aggregator.Aggregate(context.Background(), "/project")
+	if err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+
+	if len(result) != 2 {
+		t.Fatalf("expected 2 license files, got %d", len(result))
+	}
+	for _, lf := range result {
+		if lf.Path == "/vendor/github.com/foo/bar/v2/LICENSE" && lf.Module.Path != "github.com/foo/bar/v2" {
+			t.Errorf("nested license attributed to %s, want github.com/foo/bar/v2", lf.Module.Path)
+		}
+	}
+}